@@ -0,0 +1,80 @@
+package gorm_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/gorm/clause"
+)
+
+type ArrayPost struct {
+	ID     uint
+	Title  string
+	Tags   []string
+	Scores []int64
+	Ratios []float64
+	Flags  []bool
+}
+
+func TestPostgresArrayDataType(t *testing.T) {
+	// data types are built without querying, so the dialect isn't bound to any connection
+	dialect, ok := gorm.GetDialect("postgres")
+	if !ok {
+		t.Fatalf("Should get postgres dialect")
+	}
+
+	expects := map[string]string{"Tags": "text[]", "Scores": "bigint[]", "Ratios": "double precision[]", "Flags": "boolean[]"}
+	scope := DB.NewScope(&ArrayPost{})
+	for name, expect := range expects {
+		field, _ := scope.FieldByName(name)
+		if dataType := dialect.DataTypeOf(field.StructField); dataType != expect {
+			t.Errorf("Data type of %v should be %v, but got %v", name, expect, dataType)
+		}
+	}
+}
+
+func TestPostgresArray(t *testing.T) {
+	if dialect := os.Getenv("GORM_DIALECT"); dialect != "postgres" {
+		t.Skip("Skipping this because only postgres supports array columns")
+	}
+
+	DB.DropTableIfExists(&ArrayPost{})
+	if err := DB.AutoMigrate(&ArrayPost{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate, got %v", err)
+	}
+
+	post := ArrayPost{Title: "array", Tags: []string{"go", "orm"}, Scores: []int64{1, 2}, Ratios: []float64{0.5}, Flags: []bool{true, false}}
+	if err := DB.Save(&post).Error; err != nil {
+		t.Fatalf("Should save array fields, but got %v", err)
+	}
+	DB.Save(&ArrayPost{Title: "array2", Tags: []string{"sql"}})
+
+	var result ArrayPost
+	if err := DB.First(&result, post.ID).Error; err != nil {
+		t.Fatalf("Should find record, but got %v", err)
+	}
+
+	if !reflect.DeepEqual(result.Tags, post.Tags) || !reflect.DeepEqual(result.Scores, post.Scores) || !reflect.DeepEqual(result.Ratios, post.Ratios) || !reflect.DeepEqual(result.Flags, post.Flags) {
+		t.Errorf("Should scan array fields, but got %#v", result)
+	}
+
+	DB.Model(&result).Update("tags", []string{"go", "orm", "db"})
+	if DB.First(&result, post.ID); len(result.Tags) != 3 {
+		t.Errorf("Should update array fields, but got %v", result.Tags)
+	}
+
+	var posts []ArrayPost
+	if DB.Where(clause.Contains("Tags", []string{"go", "db"})).Find(&posts); len(posts) != 1 || posts[0].ID != post.ID {
+		t.Errorf("Should find records with contains condition, but got %v", posts)
+	}
+
+	if DB.Where(clause.Overlaps("tags", []string{"db", "sql"})).Find(&posts); len(posts) != 2 {
+		t.Errorf("Should find records with overlaps condition, but got %v", posts)
+	}
+
+	if DB.Where(clause.Any("tags", "sql")).Find(&posts); len(posts) != 1 || posts[0].Title != "array2" {
+		t.Errorf("Should find records with any condition, but got %v", posts)
+	}
+}
@@ -0,0 +1,224 @@
+// Package audit records changes of models into the `audit_logs` table
+//     audit.Register(db)
+//     db.AutoMigrate(&audit.Log{})
+//
+//     func (User) Auditable() bool { return true }
+//
+//     db.Set("audit:actor", "jinzhu").Save(&user)
+//     db.WithContext(audit.WithActor(ctx, "jinzhu")).Delete(&user)
+package audit
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Operations of audit logs
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// Auditable is the interface for models that should be audited
+type Auditable interface {
+	Auditable() bool
+}
+
+// Log is a record of model changes
+type Log struct {
+	ID         uint `gorm:"primary_key"`
+	ModelName  string
+	PrimaryKey string
+	Operation  string
+	Changes    string `gorm:"type:text"`
+	Actor      string
+	CreatedAt  time.Time
+}
+
+// TableName returns the table name of audit logs
+func (Log) TableName() string {
+	return "audit_logs"
+}
+
+// Change is the change of a column, which is marshaled into `Log.Changes` keyed by column names
+type Change struct {
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+type actorKey struct{}
+
+// WithActor returns a context carrying the actor, which will be used when `audit:actor` is not set
+func WithActor(ctx context.Context, actor interface{}) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Register register audit callbacks, fields tagged with `gorm:"audit:-"` won't be audited
+func Register(db *gorm.DB) {
+	callback := db.Callback()
+	callback.Create().After("gorm:create").Register("audit:create", createCallback)
+	callback.Update().Before("gorm:update").Register("audit:before_update", loadOldValuesCallback)
+	callback.Update().After("gorm:update").Register("audit:update", updateCallback)
+	callback.Delete().Before("gorm:delete").Register("audit:before_delete", loadOldValuesCallback)
+	callback.Delete().After("gorm:delete").Register("audit:delete", deleteCallback)
+}
+
+func isAuditable(scope *gorm.Scope) bool {
+	if scope.HasError() || scope.IndirectValue().Kind() != reflect.Struct {
+		return false
+	}
+
+	if auditable, ok := scope.Value.(Auditable); ok && auditable.Auditable() {
+		_, ok := primaryKey(scope)
+		return ok
+	}
+	return false
+}
+
+func primaryKey(scope *gorm.Scope) (string, bool) {
+	var values []string
+	for _, field := range scope.PrimaryFields() {
+		if !field.Field.IsValid() || reflect.DeepEqual(field.Field.Interface(), reflect.Zero(field.Field.Type()).Interface()) {
+			return "", false
+		}
+		values = append(values, fmt.Sprint(field.Field.Interface()))
+	}
+	return strings.Join(values, ","), len(values) > 0
+}
+
+// loadValues query current values of the record inside the transaction of the scope
+func loadValues(scope *gorm.Scope) (map[string]interface{}, error) {
+	var (
+		record     = reflect.New(scope.GetModelStruct().ModelType)
+		conditions = map[string]interface{}{}
+	)
+	for _, field := range scope.PrimaryFields() {
+		conditions[field.DBName] = field.Field.Interface()
+	}
+
+	if err := scope.NewDB().Set("gorm:track_changes", false).Unscoped().Table(scope.TableName()).Where(conditions).First(record.Interface()).Error; err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	for _, field := range scope.New(record.Interface()).Fields() {
+		if setting, _ := field.TagSettingsGet("AUDIT"); field.IsNormal && !field.IsIgnored && setting != "-" {
+			values[field.DBName] = fieldValue(field.Field)
+		}
+	}
+	return values, nil
+}
+
+// fieldValue returns value of the field that could be compared and marshaled
+func fieldValue(value reflect.Value) interface{} {
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil
+	}
+
+	if valuer, ok := value.Interface().(driver.Valuer); ok {
+		if v, err := valuer.Value(); err == nil {
+			return v
+		}
+	}
+	return reflect.Indirect(value).Interface()
+}
+
+func equalValue(a, b interface{}) bool {
+	if t1, ok := a.(time.Time); ok {
+		if t2, ok := b.(time.Time); ok {
+			return t1.Equal(t2)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func actor(scope *gorm.Scope) string {
+	if value, ok := scope.Get("audit:actor"); ok && value != nil {
+		return fmt.Sprint(value)
+	}
+
+	if value := scope.Context().Value(actorKey{}); value != nil {
+		return fmt.Sprint(value)
+	}
+	return ""
+}
+
+// writeLog write changes of the record into audit logs, nothing will be written if no column changed
+func writeLog(scope *gorm.Scope, operation string, oldValues, newValues map[string]interface{}) {
+	changes := map[string]Change{}
+	for column, value := range newValues {
+		if old, ok := oldValues[column]; !ok || !equalValue(old, value) {
+			changes[column] = Change{Old: oldValues[column], New: value}
+		}
+	}
+	for column, old := range oldValues {
+		if _, ok := newValues[column]; !ok {
+			changes[column] = Change{Old: old}
+		}
+	}
+
+	if len(changes) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(changes)
+	if scope.Err(err) != nil {
+		return
+	}
+
+	pk, _ := primaryKey(scope)
+	scope.Err(scope.NewDB().Create(&Log{
+		ModelName:  scope.GetModelStruct().ModelType.Name(),
+		PrimaryKey: pk,
+		Operation:  operation,
+		Changes:    string(data),
+		Actor:      actor(scope),
+	}).Error)
+}
+
+func createCallback(scope *gorm.Scope) {
+	if isAuditable(scope) {
+		if newValues, err := loadValues(scope); scope.Err(err) == nil {
+			writeLog(scope, OperationCreate, nil, newValues)
+		}
+	}
+}
+
+// loadOldValuesCallback query old values of the record before updating or deleting
+func loadOldValuesCallback(scope *gorm.Scope) {
+	if isAuditable(scope) {
+		if oldValues, err := loadValues(scope); err == nil {
+			scope.InstanceSet("audit:old_values", oldValues)
+		} else if !gorm.IsRecordNotFoundError(err) {
+			scope.Err(err)
+		}
+	}
+}
+
+func updateCallback(scope *gorm.Scope) {
+	oldValues, ok := scope.InstanceGet("audit:old_values")
+	if !ok || scope.HasError() || scope.DB().RowsAffected == 0 {
+		return
+	}
+
+	if newValues, err := loadValues(scope); scope.Err(err) == nil {
+		writeLog(scope, OperationUpdate, oldValues.(map[string]interface{}), newValues)
+	}
+}
+
+func deleteCallback(scope *gorm.Scope) {
+	oldValues, ok := scope.InstanceGet("audit:old_values")
+	if !ok || scope.HasError() || scope.DB().RowsAffected == 0 {
+		return
+	}
+
+	writeLog(scope, OperationDelete, oldValues.(map[string]interface{}), nil)
+}
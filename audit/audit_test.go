@@ -0,0 +1,124 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/gorm/audit"
+	"github.com/jinzhu/gorm/internal/dbtest"
+)
+
+type Account struct {
+	gorm.Model
+	Name     string
+	Balance  int
+	Password string `gorm:"audit:-"`
+}
+
+func (Account) Auditable() bool {
+	return true
+}
+
+type Note struct {
+	ID   uint
+	Body string
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	db, err := dbtest.Open("gorm_audit")
+	if err != nil {
+		t.Fatalf("Failed to open database, got %v", err)
+	}
+
+	audit.Register(db)
+	db.DropTableIfExists(&Account{}, &Note{}, &audit.Log{})
+	db.AutoMigrate(&Account{}, &Note{}, &audit.Log{})
+	return db
+}
+
+func lastLog(t *testing.T, db *gorm.DB) (audit.Log, map[string]audit.Change) {
+	var (
+		log     audit.Log
+		changes map[string]audit.Change
+	)
+	if err := db.Last(&log).Error; err != nil {
+		t.Fatalf("Should find audit log, but got %v", err)
+	}
+	if err := json.Unmarshal([]byte(log.Changes), &changes); err != nil {
+		t.Fatalf("Should unmarshal changes, but got %v", err)
+	}
+	return log, changes
+}
+
+func TestAudit(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	account := Account{Name: "audit", Balance: 10, Password: "secret"}
+	db.Set("audit:actor", "creator").Create(&account)
+
+	log, changes := lastLog(t, db)
+	if log.ModelName != "Account" || log.Operation != audit.OperationCreate || log.Actor != "creator" || log.PrimaryKey != "1" {
+		t.Errorf("Should write audit log after creating, but got %#v", log)
+	}
+
+	if changes["name"].New != "audit" || changes["name"].Old != nil {
+		t.Errorf("Should record new values, but got %v", changes)
+	}
+
+	if _, ok := changes["password"]; ok {
+		t.Errorf("Excluded fields should not be recorded")
+	}
+
+	db.WithContext(audit.WithActor(context.Background(), "updater")).Model(&account).Updates(map[string]interface{}{"balance": 20, "password": "changed"})
+
+	log, changes = lastLog(t, db)
+	if log.Operation != audit.OperationUpdate || log.Actor != "updater" {
+		t.Errorf("Should write audit log after updating, but got %#v", log)
+	}
+
+	if change := changes["balance"]; change.Old != float64(10) || change.New != float64(20) {
+		t.Errorf("Should record old and new values, but got %v", changes)
+	}
+
+	if _, ok := changes["name"]; ok {
+		t.Errorf("Unchanged fields should not be recorded, but got %v", changes)
+	}
+
+	var count int
+	db.Model(&account).UpdateColumn("password", "excluded")
+	if db.Model(&audit.Log{}).Count(&count); count != 2 {
+		t.Errorf("Should not write audit log if only excluded fields changed, but got %v logs", count)
+	}
+
+	db.Delete(&account)
+	log, changes = lastLog(t, db)
+	if log.Operation != audit.OperationDelete || log.Actor != "" {
+		t.Errorf("Should write audit log after deleting, but got %#v", log)
+	}
+
+	if change := changes["name"]; change.Old != "audit" || change.New != nil {
+		t.Errorf("Should record deleted values, but got %v", changes)
+	}
+
+	db.Create(&Note{Body: "not audited"})
+	if db.Model(&audit.Log{}).Count(&count); count != 3 {
+		t.Errorf("Models not auditable should not be audited, but got %v logs", count)
+	}
+}
+
+func TestAuditRollback(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	tx := db.Begin()
+	tx.Create(&Account{Name: "rollback"})
+	tx.Rollback()
+
+	var count int
+	if db.Model(&audit.Log{}).Count(&count); count != 0 {
+		t.Errorf("Audit logs should be written in the same transaction, but got %v logs", count)
+	}
+}
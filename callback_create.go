@@ -3,6 +3,7 @@ package gorm
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 )
@@ -17,11 +18,14 @@ func init() {
 	DefaultCallback.Create().Register("gorm:before_create", beforeCreateCallback)
 	DefaultCallback.Create().Register("gorm:save_before_associations", saveBeforeAssociationsCallback)
 	DefaultCallback.Create().Register("gorm:update_time_stamp", updateTimeStampForCreateCallback)
+	DefaultCallback.Create().Register("gorm:encrypt_fields", encryptFieldsCallback)
 	DefaultCallback.Create().Register("gorm:create", createCallback)
+	DefaultCallback.Create().Register("gorm:restore_encrypted_fields", restoreEncryptedFieldsCallback)
 	DefaultCallback.Create().Register("gorm:force_reload_after_create", forceReloadAfterCreateCallback)
 	DefaultCallback.Create().Register("gorm:save_after_associations", saveAfterAssociationsCallback)
 	DefaultCallback.Create().Register("gorm:after_create", afterCreateCallback)
 	DefaultCallback.Create().Register("gorm:commit_or_rollback_transaction", commitOrRollbackTransactionCallback)
+	DefaultCallback.Create().Register("gorm:invalidate_query_cache", invalidateQueryCacheCallback)
 }
 
 // beforeCreateCallback will invoke `BeforeSave`, `BeforeCreate` method before creating
@@ -87,11 +91,11 @@ func createCallback(scope *Scope) {
 			}
 			placeholders = []string{}
 			for _, column := range columns {
+				field, _ := scope.FieldByName(column)
 				if fieldValue, ok := obj[column]; ok {
-					placeholders = append(placeholders, scope.AddToVars(fieldValue))
+					placeholders = append(placeholders, scope.AddToVars(field.serialize(fieldValue)))
 				} else {
-					field, _ := scope.FieldByName(column)
-					placeholders = append(placeholders, scope.AddToVars(field.Field.Interface()))
+					placeholders = append(placeholders, scope.AddToVars(field.serialize(field.Field.Interface())))
 				}
 			}
 			placeholdersStrings = append(placeholdersStrings, "("+strings.Join(placeholders, ",")+")")
@@ -101,6 +105,24 @@ func createCallback(scope *Scope) {
 		}
 		columnsString = strings.Join(columns, ",")
 		placeholdersString = strings.Join(placeholdersStrings, ",")
+	} else if values := scope.IndirectValue(); values.Kind() == reflect.Map {
+		// Map, columns are sorted to generate stable sql
+		if values.Type().Key().Kind() != reflect.String {
+			scope.Err(fmt.Errorf("unsupported map %v to create, should be keyed by column names", values.Type()))
+			return
+		}
+
+		var keys []string
+		for _, key := range values.MapKeys() {
+			keys = append(keys, fmt.Sprint(key.Interface()))
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			columns = append(columns, scope.Quote(key))
+			placeholders = append(placeholders, scope.AddToVars(values.MapIndex(reflect.ValueOf(key).Convert(values.Type().Key())).Interface()))
+		}
+		columnsString = strings.Join(columns, ",")
+		placeholdersString = "(" + strings.Join(placeholders, ",") + ")"
 	} else {
 		// Normal
 		for _, field := range scope.Fields() {
@@ -111,7 +133,7 @@ func createCallback(scope *Scope) {
 						scope.InstanceSet("gorm:blank_columns_with_default_value", blankColumnsWithDefaultValue)
 					} else if !field.IsPrimaryKey || !field.IsBlank {
 						columns = append(columns, scope.Quote(field.DBName))
-						placeholders = append(placeholders, scope.AddToVars(field.Field.Interface()))
+						placeholders = append(placeholders, scope.AddToVars(field.serialize(field.Field.Interface())))
 					}
 				} else if field.Relationship != nil && field.Relationship.Kind == "belongs_to" {
 					for _, foreignKey := range field.Relationship.ForeignDBNames {
@@ -202,7 +224,7 @@ func createCallback(scope *Scope) {
 
 	// execute create sql: no primaryField
 	if primaryField == nil {
-		if result, err := scope.SQLDB().Exec(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
+		if result, err := scope.execSQL(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
 			// set rows affected count
 			scope.db.RowsAffected, _ = result.RowsAffected()
 
@@ -218,7 +240,7 @@ func createCallback(scope *Scope) {
 
 	// execute create sql: lastInsertID implemention for majority of dialects
 	if lastInsertIDReturningSuffix == "" && lastInsertIDOutputInterstitial == "" {
-		if result, err := scope.SQLDB().Exec(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
+		if result, err := scope.execSQL(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
 			// set rows affected count
 			scope.db.RowsAffected, _ = result.RowsAffected()
 
@@ -234,7 +256,7 @@ func createCallback(scope *Scope) {
 
 	// execute create sql: dialects with additional lastInsertID requirements (currently postgres & mssql)
 	if primaryField.Field.CanAddr() {
-		if err := scope.SQLDB().QueryRow(scope.SQL, scope.SQLVars...).Scan(primaryField.Field.Addr().Interface()); scope.Err(err) == nil {
+		if err := scope.queryRowSQL(scope.SQL, scope.SQLVars...).Scan(primaryField.Field.Addr().Interface()); scope.Err(err) == nil {
 			primaryField.IsBlank = false
 			scope.db.RowsAffected = 1
 			if values, ok := scope.Get("gorm:create_many"); ok {
@@ -9,9 +9,12 @@ import (
 func init() {
 	DefaultCallback.Delete().Register("gorm:begin_transaction", beginTransactionCallback)
 	DefaultCallback.Delete().Register("gorm:before_delete", beforeDeleteCallback)
+	DefaultCallback.Delete().Register("gorm:save_history", saveHistoryCallback)
 	DefaultCallback.Delete().Register("gorm:delete", deleteCallback)
 	DefaultCallback.Delete().Register("gorm:after_delete", afterDeleteCallback)
 	DefaultCallback.Delete().Register("gorm:commit_or_rollback_transaction", commitOrRollbackTransactionCallback)
+	DefaultCallback.Delete().Register("gorm:invalidate_query_cache", invalidateQueryCacheCallback)
+	DefaultCallback.Delete().Register("gorm:delete_snapshot", deleteSnapshotCallback)
 }
 
 // beforeDeleteCallback will invoke `BeforeDelete` method before deleting
@@ -4,13 +4,17 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 // Define callbacks for querying
 func init() {
+	DefaultCallback.Query().Register("gorm:query_cache", queryCacheCallback)
 	DefaultCallback.Query().Register("gorm:query", queryCallback)
+	DefaultCallback.Query().Register("gorm:save_query_cache", saveQueryCacheCallback)
 	DefaultCallback.Query().Register("gorm:preload", preloadCallback)
 	DefaultCallback.Query().Register("gorm:after_query", afterQueryCallback)
+	DefaultCallback.Query().Register("gorm:snapshot", snapshotQueryCallback)
 }
 
 // queryCallback used to query data from database
@@ -19,6 +23,11 @@ func queryCallback(scope *Scope) {
 		return
 	}
 
+	// results are found from the query cache
+	if _, hit := scope.InstanceGet("gorm:query_cache_hit"); hit {
+		return
+	}
+
 	//we are only preloading relations, dont touch base model
 	if _, skip := scope.InstanceGet("gorm:only_preload"); skip {
 		return
@@ -29,19 +38,9 @@ func queryCallback(scope *Scope) {
 	var (
 		isSlice, isPtr bool
 		resultType     reflect.Type
-		results        = scope.IndirectValue()
+		results        = queryDestination(scope)
 	)
 
-	if orderBy, ok := scope.Get("gorm:order_by_primary_key"); ok {
-		if primaryField := scope.PrimaryField(); primaryField != nil {
-			scope.Search.Order(fmt.Sprintf("%v.%v %v", scope.QuotedTableName(), scope.Quote(primaryField.DBName), orderBy))
-		}
-	}
-
-	if value, ok := scope.Get("gorm:query_destination"); ok {
-		results = indirect(reflect.ValueOf(value))
-	}
-
 	if kind := results.Kind(); kind == reflect.Slice {
 		isSlice = true
 		resultType = results.Type().Elem()
@@ -51,25 +50,21 @@ func queryCallback(scope *Scope) {
 			isPtr = true
 			resultType = resultType.Elem()
 		}
+	} else if kind == reflect.Map {
+		if results.IsNil() {
+			results.Set(reflect.MakeMap(results.Type()))
+		}
 	} else if kind != reflect.Struct {
-		scope.Err(errors.New("unsupported destination, should be slice or struct"))
+		scope.Err(errors.New("unsupported destination, should be slice, map or struct"))
 		return
 	}
 
-	scope.prepareQuerySQL()
+	prepareQueryCallbackSQL(scope)
 
 	if !scope.HasError() {
 		scope.db.RowsAffected = 0
 
-		if str, ok := scope.Get("gorm:query_hint"); ok {
-			scope.SQL = fmt.Sprint(str) + scope.SQL
-		}
-
-		if str, ok := scope.Get("gorm:query_option"); ok {
-			scope.SQL += addExtraSpaceIfExist(fmt.Sprint(str))
-		}
-
-		if rows, err := scope.SQLDB().Query(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
+		if rows, err := scope.querySQL(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
 			defer rows.Close()
 
 			columns, _ := rows.Columns()
@@ -81,7 +76,14 @@ func queryCallback(scope *Scope) {
 					elem = reflect.New(resultType).Elem()
 				}
 
-				scope.scan(rows, columns, scope.New(elem.Addr().Interface()).Fields())
+				if elem.Kind() == reflect.Map {
+					if elem.IsNil() {
+						elem.Set(reflect.MakeMap(resultType))
+					}
+					scope.scanMap(rows, columns, elem)
+				} else {
+					scope.scan(rows, columns, scope.New(elem.Addr().Interface()).Fields())
+				}
 
 				if isSlice {
 					if isPtr {
@@ -101,6 +103,54 @@ func queryCallback(scope *Scope) {
 	}
 }
 
+// queryDestination returns the value that query results will be scanned into
+func queryDestination(scope *Scope) reflect.Value {
+	if value, ok := scope.Get("gorm:query_destination"); ok {
+		return indirect(reflect.ValueOf(value))
+	}
+	return scope.IndirectValue()
+}
+
+// prepareQueryCallbackSQL prepare sql for querying only once, so callbacks registered before `gorm:query` could use the sql too
+func prepareQueryCallbackSQL(scope *Scope) {
+	if _, ok := scope.InstanceGet("gorm:query_sql_prepared"); ok {
+		return
+	}
+	scope.InstanceSet("gorm:query_sql_prepared", true)
+
+	if orderBy, ok := scope.Get("gorm:order_by_primary_key"); ok {
+		if primaryField := scope.PrimaryField(); primaryField != nil {
+			scope.Search.Order(fmt.Sprintf("%v.%v %v", scope.QuotedTableName(), scope.Quote(primaryField.DBName), orderBy))
+		}
+	}
+
+	if smartSelect, ok := scope.Get("gorm:smart_select"); ok && smartSelect == true && len(scope.Search.selects) == 0 && !scope.Search.raw {
+		// select fields of the destination instead of `*`
+		if results := queryDestination(scope); results.IsValid() {
+			resultType := results.Type()
+			for resultType.Kind() == reflect.Slice || resultType.Kind() == reflect.Ptr {
+				resultType = resultType.Elem()
+			}
+
+			if resultType.Kind() == reflect.Struct {
+				if columns := scope.smartSelectColumns(resultType); len(columns) > 0 {
+					scope.Search.Select(strings.Join(columns, ", "))
+				}
+			}
+		}
+	}
+
+	scope.prepareQuerySQL()
+
+	if str, ok := scope.Get("gorm:query_hint"); ok {
+		scope.SQL = fmt.Sprint(str) + scope.SQL
+	}
+
+	if str, ok := scope.Get("gorm:query_option"); ok {
+		scope.SQL += addExtraSpaceIfExist(fmt.Sprint(str))
+	}
+}
+
 // afterQueryCallback will invoke `AfterFind` method after querying
 func afterQueryCallback(scope *Scope) {
 	if !scope.HasError() {
@@ -0,0 +1,224 @@
+package gorm
+
+import (
+	"reflect"
+	"regexp"
+	"time"
+)
+
+// maxQueryCacheDependents limits the tables tracked for invalidating dependent cached results, results won't be cached
+// if their dependents can't be tracked
+const maxQueryCacheDependents = 1024
+
+// queryCacheTablesRegexp matches tables queried in FROM and JOIN clauses, including tables of sub queries
+var queryCacheTablesRegexp = regexp.MustCompile("(?i)\\b(?:FROM|JOIN)\\s+(?:[`\"\\[]?\\w+[`\"\\]]?\\.)?[`\"\\[]?(\\w+)")
+
+type queryCacheResult struct {
+	value        reflect.Value
+	rowsAffected int64
+}
+
+// queryCacheTTL returns the cache and ttl if current query should be cached, queries in transactions are never cached,
+// as they could read uncommitted records
+func queryCacheTTL(scope *Scope) (QueryCache, time.Duration, bool) {
+	if _, ok := scope.SQLDB().(sqlTx); ok {
+		return nil, 0, false
+	}
+
+	if cache := getQueryCache(scope.db); cache != nil {
+		if value, ok := scope.Get("gorm:cache"); ok {
+			if ttl, ok := value.(time.Duration); ok && ttl > 0 {
+				return cache, ttl, true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// queryCacheCallback will set cached results to the destination and skip querying
+func queryCacheCallback(scope *Scope) {
+	if _, skip := scope.InstanceGet("gorm:skip_query_callback"); skip || scope.HasError() {
+		return
+	}
+
+	if _, skip := scope.InstanceGet("gorm:only_preload"); skip {
+		return
+	}
+
+	if cache, _, ok := queryCacheTTL(scope); ok {
+		prepareQueryCallbackSQL(scope)
+		if scope.HasError() {
+			return
+		}
+
+		if value, ok := cache.Get(scope.TableName(), scope.SQL, scope.SQLVars); ok {
+			if result, ok := value.(queryCacheResult); ok {
+				if results := queryDestination(scope); results.CanSet() && results.Type() == result.value.Type() {
+					results.Set(copyQueryCacheValue(result.value))
+					scope.db.RowsAffected = result.rowsAffected
+					// associations are still preloaded, their queries are cached with their own tables
+					scope.InstanceSet("gorm:query_cache_hit", true)
+				}
+			}
+		}
+	}
+}
+
+// saveQueryCacheCallback will save query results into the cache
+func saveQueryCacheCallback(scope *Scope) {
+	if _, hit := scope.InstanceGet("gorm:query_cache_hit"); hit || scope.HasError() {
+		return
+	}
+
+	if _, ok := scope.InstanceGet("gorm:query_sql_prepared"); !ok {
+		return
+	}
+
+	if cache, ttl, ok := queryCacheTTL(scope); ok {
+		if results := queryDestination(scope); results.IsValid() {
+			tableName := scope.TableName()
+
+			// cached results of joined tables and sub queries will be invalidated with current table
+			for _, matches := range queryCacheTablesRegexp.FindAllStringSubmatch(scope.SQL, -1) {
+				if matches[1] != tableName && !addQueryCacheDependent(scope.db, matches[1], tableName) {
+					return
+				}
+			}
+
+			cache.Set(tableName, scope.SQL, scope.SQLVars, queryCacheResult{value: copyQueryCacheValue(results), rowsAffected: scope.db.RowsAffected}, ttl)
+		}
+	}
+}
+
+// invalidateQueryCacheCallback will invalidate cached results of current table after records changed
+func invalidateQueryCacheCallback(scope *Scope) {
+	if !scope.HasError() {
+		tableName := scope.TableName()
+		invalidateQueryCache(scope.db, tableName)
+
+		// other sessions could cache records before the transaction committed
+		if tables := scope.db.txInvalidatedTables; tables != nil {
+			tables.Store(tableName, true)
+		}
+	}
+}
+
+// invalidateCommittedQueryCache invalidate tables changed in the committed transaction
+func invalidateCommittedQueryCache(db *DB) {
+	if tables := db.txInvalidatedTables; tables != nil {
+		tables.Range(func(tableName, _ interface{}) bool {
+			invalidateQueryCache(db, tableName.(string))
+			return true
+		})
+	}
+}
+
+func getQueryCache(db *DB) QueryCache {
+	db.parent.RLock()
+	defer db.parent.RUnlock()
+	return db.parent.queryCache
+}
+
+// addQueryCacheDependent records cached results of table dependent also depend on table, returns false if there are too many
+// tables tracked already
+func addQueryCacheDependent(db *DB, table, dependent string) bool {
+	db.parent.Lock()
+	defer db.parent.Unlock()
+
+	if db.parent.queryCacheDependents == nil {
+		db.parent.queryCacheDependents = map[string]map[string]bool{}
+	}
+	dependents := db.parent.queryCacheDependents[table]
+	if dependents == nil {
+		if len(db.parent.queryCacheDependents) >= maxQueryCacheDependents {
+			return false
+		}
+		dependents = map[string]bool{}
+		db.parent.queryCacheDependents[table] = dependents
+	}
+	if !dependents[dependent] && len(dependents) >= maxQueryCacheDependents {
+		return false
+	}
+	dependents[dependent] = true
+	return true
+}
+
+// invalidateQueryCache invalidate cached results of the table and tables depend on it
+func invalidateQueryCache(db *DB, table string) {
+	db.parent.RLock()
+	cache, tables := db.parent.queryCache, []string{table}
+	for dependent := range db.parent.queryCacheDependents[table] {
+		tables = append(tables, dependent)
+	}
+	db.parent.RUnlock()
+
+	if cache != nil {
+		for _, table := range tables {
+			cache.Invalidate(table)
+		}
+	}
+}
+
+// copyQueryCacheValue deep copy the value, so changing the results or records they point to won't change the cached value
+func copyQueryCacheValue(value reflect.Value) reflect.Value {
+	copied := reflect.New(value.Type()).Elem()
+	deepCopyValue(copied, value, map[uintptr]reflect.Value{})
+	return copied
+}
+
+func deepCopyValue(dst, src reflect.Value, pointers map[uintptr]reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		// keep shared and circular references
+		if copied, ok := pointers[src.Pointer()]; ok {
+			dst.Set(copied)
+			return
+		}
+		copied := reflect.New(src.Type().Elem())
+		pointers[src.Pointer()] = copied
+		deepCopyValue(copied.Elem(), src.Elem(), pointers)
+		dst.Set(copied)
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		copied := reflect.New(src.Elem().Type()).Elem()
+		deepCopyValue(copied, src.Elem(), pointers)
+		dst.Set(copied)
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			deepCopyValue(dst.Index(i), src.Index(i), pointers)
+		}
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			deepCopyValue(dst.Index(i), src.Index(i), pointers)
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		for _, key := range src.MapKeys() {
+			value := reflect.New(src.Type().Elem()).Elem()
+			deepCopyValue(value, src.MapIndex(key), pointers)
+			dst.SetMapIndex(key, value)
+		}
+	case reflect.Struct:
+		// unexported fields are copied by value
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				deepCopyValue(dst.Field(i), src.Field(i), pointers)
+			}
+		}
+	default:
+		dst.Set(src)
+	}
+}
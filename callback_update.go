@@ -14,10 +14,15 @@ func init() {
 	DefaultCallback.Update().Register("gorm:before_update", beforeUpdateCallback)
 	DefaultCallback.Update().Register("gorm:save_before_associations", saveBeforeAssociationsCallback)
 	DefaultCallback.Update().Register("gorm:update_time_stamp", updateTimeStampForUpdateCallback)
+	DefaultCallback.Update().Register("gorm:save_history", saveHistoryCallback)
+	DefaultCallback.Update().Register("gorm:encrypt_fields", encryptFieldsCallback)
 	DefaultCallback.Update().Register("gorm:update", updateCallback)
+	DefaultCallback.Update().Register("gorm:restore_encrypted_fields", restoreEncryptedFieldsCallback)
 	DefaultCallback.Update().Register("gorm:save_after_associations", saveAfterAssociationsCallback)
 	DefaultCallback.Update().Register("gorm:after_update", afterUpdateCallback)
 	DefaultCallback.Update().Register("gorm:commit_or_rollback_transaction", commitOrRollbackTransactionCallback)
+	DefaultCallback.Update().Register("gorm:invalidate_query_cache", invalidateQueryCacheCallback)
+	DefaultCallback.Update().Register("gorm:delete_snapshot", deleteSnapshotCallback)
 }
 
 // assignUpdatingAttributesCallback assign updating attributes to model
@@ -70,6 +75,9 @@ func updateCallback(scope *Scope) {
 
 			for _, column := range columns {
 				value := updateMap[column]
+				if field, ok := scope.FieldByName(column); ok {
+					value = field.serialize(value)
+				}
 				sqls = append(sqls, fmt.Sprintf("%v = %v", scope.Quote(column), scope.AddToVars(value)))
 			}
 		} else {
@@ -77,7 +85,7 @@ func updateCallback(scope *Scope) {
 				if scope.changeableField(field) {
 					if !field.IsPrimaryKey && field.IsNormal && (field.Name != "CreatedAt" || !field.IsBlank) {
 						if !field.IsForeignKey || !field.IsBlank || !field.HasDefaultValue {
-							sqls = append(sqls, fmt.Sprintf("%v = %v", scope.Quote(field.DBName), scope.AddToVars(field.Field.Interface())))
+							sqls = append(sqls, fmt.Sprintf("%v = %v", scope.Quote(field.DBName), scope.AddToVars(field.serialize(field.Field.Interface()))))
 						}
 					} else if relationship := field.Relationship; relationship != nil && relationship.Kind == "belongs_to" {
 						for _, foreignKey := range relationship.ForeignDBNames {
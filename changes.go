@@ -0,0 +1,207 @@
+package gorm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// changeTracker keeps snapshots of records found in the session returned by `TrackChanges`, keyed by table name and primary keys,
+// snapshots are dropped after the records saved or deleted, or the session reset with `ResetChanges`
+type changeTracker struct {
+	snapshots sync.Map
+}
+
+// FieldChange is the change of a field since the record was found
+type FieldChange struct {
+	Old interface{}
+	New interface{}
+}
+
+// Changes returns changed fields of the record since it was found with the session returned by `TrackChanges`, keyed by field names
+//     func (user *User) BeforeSave(scope *gorm.Scope) error {
+//       if change, ok := scope.Changes()["Email"]; ok {
+//         // email changed from change.Old to change.New
+//       }
+//       return nil
+//     }
+func (scope *Scope) Changes() map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	if snapshot, ok := scope.snapshot(); ok {
+		for _, field := range scope.Fields() {
+			if old, ok := snapshot[field.DBName]; ok {
+				if value := snapshotValue(field.Field); !equalSnapshotValue(old, value) {
+					changes[field.Name] = FieldChange{Old: old, New: value}
+				}
+			}
+		}
+	}
+	return changes
+}
+
+// Changed check if any of the fields changed since the record was found, check all fields if no field given
+func (scope *Scope) Changed(fields ...string) bool {
+	changes := scope.Changes()
+	if len(fields) == 0 {
+		return len(changes) > 0
+	}
+
+	for _, name := range fields {
+		if field, ok := scope.FieldByName(name); ok {
+			if _, ok := changes[field.Name]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectChangedFields select changed fields to update if the record has snapshot, so columns changed by others won't be overwritten
+func (scope *Scope) selectChangedFields() {
+	if _, ok := scope.snapshot(); !ok || len(scope.Search.selects) > 0 {
+		return
+	}
+
+	var (
+		names   []string
+		changes = scope.Changes()
+	)
+	for _, field := range scope.Fields() {
+		if _, ok := changes[field.Name]; ok || field.Name == "UpdatedAt" || (!field.IsNormal && field.Relationship != nil) {
+			names = append(names, field.Name)
+		}
+	}
+
+	if len(names) == 0 {
+		// nothing to update
+		names = append(names, scope.PrimaryField().Name)
+	}
+	scope.Search.Select(names)
+}
+
+// changeTracker returns the change tracker of current session
+func (scope *Scope) changeTracker() (*changeTracker, bool) {
+	value, _ := scope.Get("gorm:track_changes")
+	tracker, ok := value.(*changeTracker)
+	return tracker, ok
+}
+
+// snapshotKey returns the key of snapshot, which is composed of table name and primary keys
+func (scope *Scope) snapshotKey() (string, bool) {
+	var primaryValues []interface{}
+	for _, field := range scope.PrimaryFields() {
+		// check the value directly, as `IsBlank` of cached fields might be outdated after querying
+		if !field.Field.IsValid() || isBlank(field.Field) {
+			return "", false
+		}
+		primaryValues = append(primaryValues, field.Field.Interface())
+	}
+	return fmt.Sprintf("%v\x00%v", scope.TableName(), primaryValues), len(primaryValues) > 0
+}
+
+func (scope *Scope) snapshot() (map[string]interface{}, bool) {
+	if tracker, ok := scope.changeTracker(); ok {
+		if key, ok := scope.snapshotKey(); ok {
+			if snapshot, ok := tracker.snapshots.Load(key); ok {
+				return snapshot.(map[string]interface{}), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// saveSnapshot save values of normal fields
+func (scope *Scope) saveSnapshot() {
+	tracker, ok := scope.changeTracker()
+	if !ok {
+		return
+	}
+
+	key, ok := scope.snapshotKey()
+	if !ok {
+		return
+	}
+
+	snapshot := map[string]interface{}{}
+	for _, field := range scope.Fields() {
+		if field.IsNormal && !field.IsIgnored {
+			snapshot[field.DBName] = snapshotValue(field.Field)
+		}
+	}
+	tracker.snapshots.Store(key, snapshot)
+}
+
+// reset drops all snapshots of the session
+func (tracker *changeTracker) reset() {
+	tracker.snapshots.Range(func(key, _ interface{}) bool {
+		tracker.snapshots.Delete(key)
+		return true
+	})
+}
+
+func (scope *Scope) deleteSnapshot() {
+	if tracker, ok := scope.changeTracker(); ok {
+		if key, ok := scope.snapshotKey(); ok {
+			tracker.snapshots.Delete(key)
+		}
+	}
+}
+
+// snapshotValue returns value of the field that could be compared later, values of `driver.Valuer` are used, pointers are dereferenced and bytes are copied
+func snapshotValue(value reflect.Value) interface{} {
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil
+	}
+
+	if valuer, ok := value.Interface().(driver.Valuer); ok {
+		if v, err := valuer.Value(); err == nil {
+			return v
+		}
+	}
+
+	value = reflect.Indirect(value)
+	if b, ok := value.Interface().([]byte); ok {
+		return append([]byte{}, b...)
+	}
+	return value.Interface()
+}
+
+func equalSnapshotValue(a, b interface{}) bool {
+	if t1, ok := a.(time.Time); ok {
+		if t2, ok := b.(time.Time); ok {
+			return t1.Equal(t2)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// snapshotQueryCallback will save snapshots of found records when tracking changes
+func snapshotQueryCallback(scope *Scope) {
+	if _, ok := scope.changeTracker(); !ok || scope.HasError() {
+		return
+	}
+
+	if _, ok := scope.Get("gorm:query_destination"); ok {
+		return
+	}
+
+	switch results := scope.IndirectValue(); results.Kind() {
+	case reflect.Slice:
+		for i := 0; i < results.Len(); i++ {
+			if elem := reflect.Indirect(results.Index(i)); elem.Kind() == reflect.Struct && elem.CanAddr() {
+				scope.New(elem.Addr().Interface()).saveSnapshot()
+			}
+		}
+	case reflect.Struct:
+		scope.saveSnapshot()
+	}
+}
+
+// deleteSnapshotCallback will delete snapshot of saved or deleted record
+func deleteSnapshotCallback(scope *Scope) {
+	if !scope.HasError() && scope.IndirectValue().Kind() == reflect.Struct {
+		scope.deleteSnapshot()
+	}
+}
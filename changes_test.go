@@ -0,0 +1,136 @@
+package gorm_test
+
+import (
+	"testing"
+
+	"github.com/jinzhu/gorm"
+)
+
+type ChangesUser struct {
+	gorm.Model
+	Name    string
+	Email   string
+	Age     int
+	Changes map[string]gorm.FieldChange `sql:"-"`
+}
+
+func (user *ChangesUser) BeforeSave(scope *gorm.Scope) error {
+	user.Changes = scope.Changes()
+	return nil
+}
+
+func TestTrackChanges(t *testing.T) {
+	DB.DropTableIfExists(&ChangesUser{})
+	DB.AutoMigrate(&ChangesUser{})
+
+	DB.Save(&ChangesUser{Name: "changes", Email: "changes@example.org", Age: 10})
+
+	var user ChangesUser
+	tracked := DB.TrackChanges()
+	tracked.First(&user, "name = ?", "changes")
+
+	if tracked.Changed(&user) {
+		t.Errorf("Found record should not be changed")
+	}
+
+	user.Email = "changed@example.org"
+	if !tracked.Changed(&user, "Email") || !tracked.Changed(&user, "email") || tracked.Changed(&user, "Name") || !tracked.Changed(&user) {
+		t.Errorf("Should check changed fields")
+	}
+
+	if change, ok := tracked.Changes(&user)["Email"]; !ok || change.Old != "changes@example.org" || change.New != "changed@example.org" || len(tracked.Changes(&user)) != 1 {
+		t.Errorf("Should get changes of the record, but got %v", tracked.Changes(&user))
+	}
+
+	if DB.Changed(&user) {
+		t.Errorf("Record should not be tracked by other sessions")
+	}
+
+	// changed by others
+	DB.Model(&ChangesUser{}).Where("id = ?", user.ID).UpdateColumn("age", 20)
+
+	if err := tracked.Save(&user).Error; err != nil {
+		t.Fatalf("Should save changed record, but got %v", err)
+	}
+
+	if _, ok := user.Changes["Email"]; !ok || len(user.Changes) != 1 {
+		t.Errorf("Should get changes in hooks, but got %v", user.Changes)
+	}
+
+	if tracked.Changed(&user) {
+		t.Errorf("Saved record should not be changed")
+	}
+
+	changed := user
+	if changed.Age = 40; tracked.Changed(&changed) {
+		t.Errorf("Snapshot should be dropped after saved, but got %v", tracked.Changes(&changed))
+	}
+
+	var result ChangesUser
+	DB.First(&result, user.ID)
+	if result.Email != "changed@example.org" || result.Age != 20 {
+		t.Errorf("Should only update changed columns, but got %v, %v", result.Email, result.Age)
+	}
+
+	// record found without tracking changes
+	result.Name = "changes2"
+	tracked.Save(&result)
+	DB.First(&user, user.ID)
+	if user.Name != "changes2" || user.Age != 20 || tracked.Changed(&result) {
+		t.Errorf("Should update all columns without tracking changes, but got %v", user.Name)
+	}
+
+	// record found with tracking changes, but saved without tracking
+	var trackedUser ChangesUser
+	tracked.First(&trackedUser, user.ID)
+	DB.Model(&ChangesUser{}).Where("id = ?", user.ID).UpdateColumn("age", 30)
+
+	trackedUser.Email = "changed2@example.org"
+	DB.Save(&trackedUser)
+	DB.First(&result, user.ID)
+	if result.Email != "changed2@example.org" || result.Age != 20 {
+		t.Errorf("Should update all columns when saving without tracking changes, but got %v, %v", result.Email, result.Age)
+	}
+}
+
+func TestTrackChangesByPrimaryKey(t *testing.T) {
+	DB.DropTableIfExists(&ChangesUser{})
+	DB.AutoMigrate(&ChangesUser{})
+
+	DB.Save(&ChangesUser{Name: "primary_key", Email: "primary_key@example.org", Age: 10})
+
+	var user ChangesUser
+	tracked := DB.TrackChanges()
+	tracked.First(&user, "name = ?", "primary_key")
+
+	copied := user
+	copied.Age = 20
+	if !tracked.Changed(&copied, "Age") || tracked.Changed(&copied, "Name") {
+		t.Errorf("Snapshot should be found by primary key, but got %v", tracked.Changes(&copied))
+	}
+
+	user.Name = "reset"
+	if tracked.ResetChanges(); tracked.Changed(&user) {
+		t.Errorf("Snapshots should be dropped after reset, but got %v", tracked.Changes(&user))
+	}
+
+	tracked.First(&user, user.ID)
+	user.Name = "deleted"
+	if tracked.Delete(&user); tracked.Changed(&user) {
+		t.Errorf("Snapshot should be dropped after deleted, but got %v", tracked.Changes(&user))
+	}
+
+	// the saved record isn't tracked anymore, so all columns are updated
+	var saved ChangesUser
+	tracked.Unscoped().First(&saved, user.ID)
+	tracked.Save(&saved)
+	DB.Unscoped().Model(&ChangesUser{}).Where("id = ?", user.ID).UpdateColumn("age", 30)
+
+	saved.Email = "saved@example.org"
+	tracked.Unscoped().Save(&saved)
+
+	var result ChangesUser
+	if DB.Unscoped().First(&result, user.ID); result.Email != "saved@example.org" || result.Age != 10 {
+		t.Errorf("Should update all columns of saved records, but got %v, %v", result.Email, result.Age)
+	}
+}
@@ -0,0 +1,149 @@
+package gorm
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Check named CHECK constraint
+type Check struct {
+	Name       string
+	Expression string
+}
+
+// Checker is implemented by models having table level CHECK constraints
+//     func (Product) Checks() []gorm.Check {
+//       return []gorm.Check{{Name: "chk_discount_price", Expression: "discount_price < price"}}
+//     }
+//
+// CHECK constraints of fields could be declared with tag `check`, constraint name is optional
+//     type Product struct {
+//       Price    float64 `gorm:"check:price_nonneg,price >= 0"`
+//       Quantity int     `gorm:"check:quantity >= 0"`
+//     }
+type Checker interface {
+	Checks() []Check
+}
+
+var checkNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// checkConstraint named CHECK constraint of table
+type checkConstraint struct {
+	name       string
+	expression string
+	enumValues []string
+}
+
+func (check checkConstraint) sql(scope *Scope) string {
+	return fmt.Sprintf("CONSTRAINT %v CHECK (%v)", scope.Quote(check.name), check.expression)
+}
+
+// checks returns CHECK constraints of current model, including checks of enum fields
+func (scope *Scope) checks() (checks []checkConstraint) {
+	for _, field := range scope.GetModelStruct().StructFields {
+		if str, ok := field.TagSettingsGet("CHECK"); ok && field.IsNormal && !field.IsIgnored && str != "CHECK" {
+			check := checkConstraint{
+				name:       scope.Dialect().BuildKeyName("chk", scope.TableName(), field.DBName),
+				expression: str,
+			}
+			if parts := strings.SplitN(str, ",", 2); len(parts) == 2 && checkNameRegexp.MatchString(strings.TrimSpace(parts[0])) {
+				check.name, check.expression = strings.TrimSpace(parts[0]), parts[1]
+			}
+			check.expression = strings.TrimSpace(check.expression)
+			checks = append(checks, check)
+		}
+	}
+
+	if checker, ok := reflect.New(scope.GetModelStruct().ModelType).Interface().(Checker); ok {
+		for _, check := range checker.Checks() {
+			checks = append(checks, checkConstraint{name: check.Name, expression: check.Expression})
+		}
+	}
+
+	return append(checks, scope.enumChecks()...)
+}
+
+// checkDefinition returns the definition of existing CHECK constraint
+func (scope *Scope) checkDefinition(name string) (definition string, ok bool) {
+	var row interface{ Scan(...interface{}) error }
+	switch scope.Dialect().GetName() {
+	case "postgres":
+		row = scope.NewDB().Raw("SELECT pg_get_constraintdef(con.oid) FROM pg_constraint con JOIN pg_class rel ON rel.oid = con.conrelid JOIN pg_namespace n ON n.oid = rel.relnamespace WHERE con.conname = ? AND rel.relname = ? AND con.contype = 'c' AND n.nspname = CURRENT_SCHEMA()", name, scope.TableName()).Row()
+	case "mysql":
+		row = scope.NewDB().Raw("SELECT cc.CHECK_CLAUSE FROM INFORMATION_SCHEMA.CHECK_CONSTRAINTS cc JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc ON tc.CONSTRAINT_SCHEMA = cc.CONSTRAINT_SCHEMA AND tc.CONSTRAINT_NAME = cc.CONSTRAINT_NAME WHERE cc.CONSTRAINT_SCHEMA = DATABASE() AND tc.TABLE_NAME = ? AND cc.CONSTRAINT_NAME = ?", scope.TableName(), name).Row()
+	case "mssql":
+		row = scope.NewDB().Raw("SELECT definition FROM sys.check_constraints WHERE name = ? AND parent_object_id = OBJECT_ID(?)", name, scope.TableName()).Row()
+	case "sqlite3":
+		var tableSQL string
+		scope.NewDB().Raw("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", scope.TableName()).Row().Scan(&tableSQL)
+		if idx := strings.Index(tableSQL, fmt.Sprintf("CONSTRAINT %v CHECK ", scope.Quote(name))); idx >= 0 {
+			definition = tableSQL[idx:]
+			if end := strings.Index(definition, ", CONSTRAINT "); end > 0 {
+				definition = definition[:end]
+			}
+			return strings.TrimSuffix(definition, ")"), true
+		}
+		return "", false
+	default:
+		return "", false
+	}
+
+	return definition, row.Scan(&definition) == nil
+}
+
+// migrateChecks adds missing CHECK constraints to existing tables, and replaces outdated enum constraints
+func (scope *Scope) migrateChecks() {
+	checks := scope.checks()
+	if len(checks) == 0 {
+		return
+	}
+
+	switch scope.Dialect().GetName() {
+	case "postgres", "mysql", "mssql":
+	case "sqlite3":
+		// sqlite can't alter constraints, so the table is rebuilt if any constraint is missing
+		for _, check := range checks {
+			if definition, ok := scope.checkDefinition(check.name); !ok || definition != check.sql(scope) {
+				scope.rebuildTable(checks)
+				return
+			}
+		}
+		return
+	default:
+		return
+	}
+
+	for _, check := range checks {
+		if definition, ok := scope.checkDefinition(check.name); ok {
+			if len(missingEnumValues(definition, check.enumValues)) == 0 {
+				continue
+			}
+			scope.dropCheck(check.name)
+		}
+		scope.Err(scope.NewDB().Exec(fmt.Sprintf("ALTER TABLE %v ADD %v", scope.QuotedTableName(), check.sql(scope))).Error)
+	}
+}
+
+// dropCheck drops CHECK constraint with name
+func (scope *Scope) dropCheck(name string) {
+	if _, ok := scope.checkDefinition(name); !ok {
+		return
+	}
+
+	switch scope.Dialect().GetName() {
+	case "sqlite3":
+		var checks []checkConstraint
+		for _, check := range scope.checks() {
+			if check.name != name {
+				checks = append(checks, check)
+			}
+		}
+		scope.rebuildTable(checks)
+	case "mysql":
+		scope.Err(scope.NewDB().Exec(fmt.Sprintf("ALTER TABLE %v DROP CHECK %v", scope.QuotedTableName(), scope.Quote(name))).Error)
+	default:
+		scope.Err(scope.NewDB().Exec(fmt.Sprintf("ALTER TABLE %v DROP CONSTRAINT %v", scope.QuotedTableName(), scope.Quote(name))).Error)
+	}
+}
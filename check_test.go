@@ -0,0 +1,125 @@
+package gorm_test
+
+import (
+	"testing"
+
+	"github.com/jinzhu/gorm"
+)
+
+type CheckProduct struct {
+	ID            uint
+	Name          string  `gorm:"index"`
+	Price         float64 `gorm:"check:price_nonneg,price >= 0"`
+	DiscountPrice float64
+	Quantity      int `gorm:"check:quantity >= 0"`
+}
+
+func (CheckProduct) Checks() []gorm.Check {
+	return []gorm.Check{{Name: "chk_discount_price", Expression: "discount_price <= price"}}
+}
+
+type CheckProductWithoutChecks struct {
+	ID            uint
+	Name          string `gorm:"index"`
+	Price         float64
+	DiscountPrice float64
+	Quantity      int
+}
+
+func (CheckProductWithoutChecks) TableName() string {
+	return "check_products"
+}
+
+func TestCheckConstraints(t *testing.T) {
+	DB.DropTableIfExists(&CheckProduct{})
+	if err := DB.AutoMigrate(&CheckProduct{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate, got %v", err)
+	}
+
+	if err := DB.Save(&CheckProduct{Name: "check", Price: 10, DiscountPrice: 8, Quantity: 1}).Error; err != nil {
+		t.Errorf("Should save valid values, but got %v", err)
+	}
+
+	invalids := []CheckProduct{
+		{Name: "invalid price", Price: -1, DiscountPrice: -2},
+		{Name: "invalid discount", Price: 10, DiscountPrice: 12},
+		{Name: "invalid quantity", Price: 10, Quantity: -1},
+	}
+	for _, product := range invalids {
+		if err := DB.Save(&product).Error; err == nil {
+			t.Errorf("Should not save %v", product.Name)
+		}
+	}
+
+	if err := DB.Model(&CheckProduct{}).DropCheck("price_nonneg").Error; err != nil {
+		t.Errorf("Should drop check constraint, but got %v", err)
+	}
+
+	if err := DB.Save(&CheckProduct{Name: "check", Price: -1, DiscountPrice: -2}).Error; err != nil {
+		t.Errorf("Should save values after dropping check constraint, but got %v", err)
+	}
+
+	if err := DB.Save(&CheckProduct{Name: "check", Price: 10, DiscountPrice: 12}).Error; err == nil {
+		t.Errorf("Other check constraints should be kept after dropping check constraint")
+	}
+
+	DB.Where("price < 0").Delete(&CheckProduct{})
+	if err := DB.AutoMigrate(&CheckProduct{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate missing check constraints, got %v", err)
+	}
+
+	if err := DB.Save(&CheckProduct{Name: "check", Price: -1, DiscountPrice: -2}).Error; err == nil {
+		t.Errorf("Should add missing check constraints when auto migrating")
+	}
+
+	var count int
+	if DB.Model(&CheckProduct{}).Where("name = ?", "check").Count(&count); count != 1 {
+		t.Errorf("Existing records should be kept after auto migrating, but got %v", count)
+	}
+
+	if !DB.Dialect().HasIndex("check_products", "idx_check_products_name") {
+		t.Errorf("Indexes should be kept after auto migrating check constraints")
+	}
+
+	DB.Model(&CheckProductWithoutChecks{}).DropCheck("price_nonneg").DropCheck("chk_check_products_quantity").DropCheck("chk_discount_price")
+	if err := DB.Save(&CheckProductWithoutChecks{Name: "check", Price: -1, DiscountPrice: 2, Quantity: -1}).Error; err != nil {
+		t.Errorf("Should drop check constraints not declared by model, but got %v", err)
+	}
+}
+
+type CheckEnumArticle struct {
+	ID    uint
+	State string `gorm:"enum:draft,published,archived;check:state <> 'archived'"`
+}
+
+func TestCheckConstraintsWithEnum(t *testing.T) {
+	DB.DropTableIfExists(&CheckEnumArticle{})
+	if err := DB.AutoMigrate(&CheckEnumArticle{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate, got %v", err)
+	}
+
+	if err := DB.AutoMigrate(&CheckEnumArticle{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate existing table, got %v", err)
+	}
+
+	if err := DB.Save(&CheckEnumArticle{State: "draft"}).Error; err != nil {
+		t.Errorf("Should save valid values, but got %v", err)
+	}
+
+	if err := DB.Save(&CheckEnumArticle{State: "unknown"}).Error; err == nil {
+		t.Errorf("Should enforce enum values of field with check constraint")
+	}
+
+	if err := DB.Save(&CheckEnumArticle{State: "archived"}).Error; err == nil {
+		t.Errorf("Should enforce check constraint of enum field")
+	}
+
+	DB.Model(&CheckEnumArticle{}).DropCheck("chk_check_enum_articles_state")
+	if err := DB.Save(&CheckEnumArticle{State: "archived"}).Error; err != nil {
+		t.Errorf("Should drop check constraint of enum field, but got %v", err)
+	}
+
+	if err := DB.Save(&CheckEnumArticle{State: "unknown"}).Error; err == nil {
+		t.Errorf("Should keep enforcing enum values after dropping check constraint")
+	}
+}
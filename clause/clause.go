@@ -0,0 +1,226 @@
+// Package clause contains composable condition expressions, which could be used as conditions of `Where`, `Having`, `Order` and `Joins`
+//     db.Where(clause.Or(clause.Gt("Age", 18), clause.And(clause.Like("Name", "jin%"), clause.Not(clause.Eq("Deleted", true))))).Find(&users)
+//     db.Joins("JOIN emails ON emails.user_id = users.id AND ?", clause.Like("emails.email", "%@example.org")).Find(&users)
+package clause
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Builder is used to build expressions into SQL, column names will be resolved and quoted with `Column`, values will be added as sql's vars with `AddVar`
+type Builder interface {
+	Column(name string) string
+	AddVar(value interface{}) string
+}
+
+// Expression condition expression
+type Expression interface {
+	Build(builder Builder) string
+}
+
+type comparison struct {
+	column   string
+	operator string
+	value    interface{}
+}
+
+func (c comparison) Build(builder Builder) string {
+	return fmt.Sprintf("%v %v %v", builder.Column(c.column), c.operator, builder.AddVar(c.value))
+}
+
+// Eq column = value, `IS NULL` if value is nil
+func Eq(column string, value interface{}) Expression {
+	if value == nil {
+		return IsNull(column)
+	}
+	return comparison{column, "=", value}
+}
+
+// Neq column <> value, `IS NOT NULL` if value is nil
+func Neq(column string, value interface{}) Expression {
+	if value == nil {
+		return Not(IsNull(column))
+	}
+	return comparison{column, "<>", value}
+}
+
+// Gt column > value
+func Gt(column string, value interface{}) Expression {
+	return comparison{column, ">", value}
+}
+
+// Gte column >= value
+func Gte(column string, value interface{}) Expression {
+	return comparison{column, ">=", value}
+}
+
+// Lt column < value
+func Lt(column string, value interface{}) Expression {
+	return comparison{column, "<", value}
+}
+
+// Lte column <= value
+func Lte(column string, value interface{}) Expression {
+	return comparison{column, "<=", value}
+}
+
+// Like column LIKE pattern
+func Like(column string, pattern interface{}) Expression {
+	return comparison{column, "LIKE", pattern}
+}
+
+type in struct {
+	column string
+	values []interface{}
+}
+
+// In column IN (values...), a single slice value will be expanded
+func In(column string, values ...interface{}) Expression {
+	if len(values) == 1 {
+		if reflectValue := reflect.ValueOf(values[0]); reflectValue.Kind() == reflect.Slice && reflectValue.Type().Elem().Kind() != reflect.Uint8 {
+			values = make([]interface{}, reflectValue.Len())
+			for i := 0; i < reflectValue.Len(); i++ {
+				values[i] = reflectValue.Index(i).Interface()
+			}
+		}
+	}
+	return in{column, values}
+}
+
+func (i in) Build(builder Builder) string {
+	if len(i.values) == 0 {
+		return fmt.Sprintf("%v IN (NULL)", builder.Column(i.column))
+	}
+
+	var marks []string
+	for _, value := range i.values {
+		marks = append(marks, builder.AddVar(value))
+	}
+	return fmt.Sprintf("%v IN (%v)", builder.Column(i.column), strings.Join(marks, ","))
+}
+
+type isNull struct {
+	column string
+}
+
+// IsNull column IS NULL
+func IsNull(column string) Expression {
+	return isNull{column}
+}
+
+func (n isNull) Build(builder Builder) string {
+	return fmt.Sprintf("%v IS NULL", builder.Column(n.column))
+}
+
+type between struct {
+	column   string
+	from, to interface{}
+}
+
+// Between column BETWEEN from AND to
+func Between(column string, from, to interface{}) Expression {
+	return between{column, from, to}
+}
+
+func (b between) Build(builder Builder) string {
+	column := builder.Column(b.column)
+	from := builder.AddVar(b.from)
+	return fmt.Sprintf("%v BETWEEN %v AND %v", column, from, builder.AddVar(b.to))
+}
+
+// Contains postgres array column contains all values, column @> values
+func Contains(column string, values interface{}) Expression {
+	return comparison{column, "@>", values}
+}
+
+// Overlaps postgres array column has any elements in common with values, column && values
+func Overlaps(column string, values interface{}) Expression {
+	return comparison{column, "&&", values}
+}
+
+type anyElement struct {
+	column string
+	value  interface{}
+}
+
+// Any value equals to any element of postgres array column, value = ANY(column)
+func Any(column string, value interface{}) Expression {
+	return anyElement{column, value}
+}
+
+func (a anyElement) Build(builder Builder) string {
+	return fmt.Sprintf("%v = ANY(%v)", builder.AddVar(a.value), builder.Column(a.column))
+}
+
+type combination struct {
+	operator    string
+	expressions []Expression
+}
+
+// And combine expressions with AND
+func And(expressions ...Expression) Expression {
+	return combination{"AND", expressions}
+}
+
+// Or combine expressions with OR
+func Or(expressions ...Expression) Expression {
+	return combination{"OR", expressions}
+}
+
+func (c combination) Build(builder Builder) string {
+	var sqls []string
+	for _, expression := range c.expressions {
+		if sql := expression.Build(builder); sql != "" {
+			sqls = append(sqls, sql)
+		}
+	}
+
+	if len(sqls) > 1 {
+		return "(" + strings.Join(sqls, " "+c.operator+" ") + ")"
+	}
+	return strings.Join(sqls, "")
+}
+
+type not struct {
+	expression Expression
+}
+
+// Not negate expression
+func Not(expression Expression) Expression {
+	return not{expression}
+}
+
+func (n not) Build(builder Builder) string {
+	if isNull, ok := n.expression.(isNull); ok {
+		return fmt.Sprintf("%v IS NOT NULL", builder.Column(isNull.column))
+	}
+
+	if sql := n.expression.Build(builder); sql != "" {
+		return fmt.Sprintf("NOT (%v)", sql)
+	}
+	return ""
+}
+
+type order struct {
+	column string
+	desc   bool
+}
+
+// Asc order by column ascending
+func Asc(column string) Expression {
+	return order{column, false}
+}
+
+// Desc order by column descending
+func Desc(column string) Expression {
+	return order{column, true}
+}
+
+func (o order) Build(builder Builder) string {
+	if o.desc {
+		return builder.Column(o.column) + " DESC"
+	}
+	return builder.Column(o.column) + " ASC"
+}
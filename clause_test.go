@@ -0,0 +1,55 @@
+package gorm_test
+
+import (
+	"testing"
+
+	"github.com/jinzhu/gorm/clause"
+)
+
+func TestClauseExpressions(t *testing.T) {
+	DB.Save(&User{Name: "clause_user1", Age: 10, Email: "clause1@example.org"})
+	DB.Save(&User{Name: "clause_user2", Age: 20})
+	DB.Save(&User{Name: "clause_user3", Age: 30, Email: "clause3@example.org"})
+
+	var users []User
+	DB.Where(clause.Like("Name", "clause_user%")).
+		Where(clause.Or(clause.Gt("Age", 25), clause.And(clause.Between("age", 5, 15), clause.Not(clause.Eq("Email", ""))))).
+		Order(clause.Desc("Age")).Find(&users)
+	if len(users) != 2 || users[0].Name != "clause_user3" || users[1].Name != "clause_user1" {
+		t.Errorf("Should find users with clause expressions, but got %v", len(users))
+	}
+
+	DB.Where(clause.In("Name", []string{"clause_user1", "clause_user2"})).Not(clause.Lt("Age", 15)).Find(&users)
+	if len(users) != 1 || users[0].Name != "clause_user2" {
+		t.Errorf("Should find users with in and not expressions, but got %v", len(users))
+	}
+
+	DB.Where(clause.In("Name")).Find(&users)
+	if len(users) != 0 {
+		t.Errorf("Should find no users with empty in expression, but got %v", len(users))
+	}
+
+	var count int
+	DB.Model(&User{}).Where(clause.Like("name", "clause_user%")).Where(clause.Neq("Birthday", nil)).Count(&count)
+	if count != 0 {
+		t.Errorf("Should find no users having birthday, but got %v", count)
+	}
+
+	type EmailAge struct {
+		Email string
+		Total int64
+	}
+	var results []EmailAge
+	DB.Table("users").Select("email, sum(age) AS total").Where(clause.Like("name", "clause_user%")).
+		Group("email").Having(clause.Gte("sum(age)", 20)).Order(clause.Asc("email")).Scan(&results)
+	if len(results) != 2 || results[0].Total != 20 || results[1].Total != 30 {
+		t.Errorf("Should find grouped results with having expression, but got %+v", results)
+	}
+
+	var names []string
+	DB.Table("users").Joins("JOIN users AS other ON other.email = users.email AND ?", clause.Gte("other.age", 30)).
+		Where(clause.Like("users.name", "clause_user%")).Pluck("users.name", &names)
+	if len(names) != 1 || names[0] != "clause_user3" {
+		t.Errorf("Should find users with join expression, but got %v", names)
+	}
+}
@@ -0,0 +1,428 @@
+package gorm
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// columnType type, nullability and default value of existing or expected columns
+type columnType struct {
+	name         string // lower case type name without size, e.g: varchar
+	sqlType      string // declared type, e.g: varchar(255)
+	size         int64  // size of string types, 0 means unlimited
+	precision    int64
+	scale        int64
+	notNull      bool
+	defaultValue string
+	hasDefault   bool
+	position     int
+}
+
+var columnTypeAliases = map[string]string{
+	"character varying": "varchar",
+	"nvarchar":          "varchar",
+	"character":         "char",
+	"nchar":             "char",
+	"bpchar":            "char",
+	"boolean":           "bool",
+	"bit":               "bool",
+	"tinyint":           "bool",
+	"integer":           "int",
+	"int4":              "int",
+	"serial":            "int",
+	"mediumint":         "int",
+	"int8":              "bigint",
+	"bigserial":         "bigint",
+	"int2":              "smallint",
+	"decimal":           "numeric",
+	"float4":            "real",
+	"double precision":  "double",
+	"float8":            "double",
+	"float":             "double",
+	"timestamptz":       "timestamp with time zone",
+	"datetime2":         "datetime",
+	"longtext":          "text",
+	"mediumtext":        "text",
+	"clob":              "text",
+	"longblob":          "blob",
+}
+
+// sizedColumnTypes types whose size limits the length of values
+var sizedColumnTypes = map[string]bool{"varchar": true, "char": true, "varbinary": true, "binary": true}
+
+// fractionalSecondsColumnTypes mssql time types declared with precision of fractional seconds, e.g: datetime2(3)
+var fractionalSecondsColumnTypes = map[string]bool{"datetime2": true, "datetimeoffset": true, "time": true}
+
+// widerColumnTypes types ordered from narrower to wider, changing to wider types won't lose data
+var widerColumnTypes = [][]string{
+	{"bool", "smallint", "int", "bigint"},
+	{"real", "double"},
+	{"char", "varchar", "text"},
+	{"binary", "varbinary", "blob"},
+}
+
+// parseColumnType parses declared column types like `varchar(255)`, `numeric(10,2)`
+func parseColumnType(sqlType string) (ct columnType) {
+	ct.sqlType = strings.TrimSpace(sqlType)
+	str := strings.ToLower(ct.sqlType)
+	for _, keyword := range []string{" primary key autoincrement", " auto_increment", " identity(1,1)", " unsigned", " null"} {
+		str = strings.Replace(str, keyword, "", -1)
+	}
+
+	var args []string
+	if idx := strings.Index(str, "("); idx > 0 && strings.HasSuffix(str, ")") {
+		args = strings.Split(str[idx+1:len(str)-1], ",")
+		str = str[:idx]
+	}
+
+	ct.name = strings.TrimSpace(str)
+	if alias, ok := columnTypeAliases[ct.name]; ok {
+		ct.name = alias
+	}
+
+	if len(args) > 0 {
+		if sizedColumnTypes[ct.name] {
+			ct.size, _ = strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
+		} else if ct.name == "numeric" {
+			ct.precision, _ = strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
+			if len(args) > 1 {
+				ct.scale, _ = strconv.ParseInt(strings.TrimSpace(args[1]), 10, 64)
+			}
+		}
+	}
+	return
+}
+
+// mssqlSQLType returns the declared type of existing mssql column, which is introspected without its size, precision and scale
+func (ct columnType) mssqlSQLType() string {
+	switch {
+	case sizedColumnTypes[ct.name]:
+		if ct.size == 0 {
+			return fmt.Sprintf("%v(max)", ct.sqlType)
+		}
+		return fmt.Sprintf("%v(%v)", ct.sqlType, ct.size)
+	case ct.name == "numeric" && ct.precision > 0:
+		return fmt.Sprintf("%v(%v,%v)", ct.sqlType, ct.precision, ct.scale)
+	case fractionalSecondsColumnTypes[strings.ToLower(ct.sqlType)]:
+		return fmt.Sprintf("%v(%v)", ct.sqlType, ct.precision)
+	}
+	return ct.sqlType
+}
+
+// normalizeDefaultValue removes parentheses, type casts and quotes of default values
+func normalizeDefaultValue(value string) string {
+	value = strings.TrimSpace(value)
+	for strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		value = strings.TrimSpace(value[1 : len(value)-1])
+	}
+
+	if idx := strings.LastIndex(value, "::"); idx > 0 && !strings.HasSuffix(value, "'") {
+		value = value[:idx]
+	}
+	return strings.ToLower(strings.Trim(value, "'"))
+}
+
+// typeChanged returns true if the type or size of the column changed
+func (ct columnType) typeChanged(actual columnType) bool {
+	return ct.name != actual.name ||
+		(sizedColumnTypes[ct.name] && ct.size != actual.size) ||
+		(ct.precision > 0 && (ct.precision != actual.precision || ct.scale != actual.scale))
+}
+
+// widens returns true if changing the column from actual type won't lose data
+func (ct columnType) widens(actual columnType) bool {
+	sizeWidened := !sizedColumnTypes[ct.name] || !sizedColumnTypes[actual.name] || ct.size == 0 || (actual.size > 0 && ct.size >= actual.size)
+	if ct.name == actual.name {
+		return sizeWidened && ct.scale >= actual.scale && ct.precision-ct.scale >= actual.precision-actual.scale
+	}
+
+	for _, types := range widerColumnTypes {
+		var expectedIndex, actualIndex = -1, -1
+		for idx, name := range types {
+			if name == ct.name {
+				expectedIndex = idx
+			}
+			if name == actual.name {
+				actualIndex = idx
+			}
+		}
+
+		if expectedIndex >= 0 && actualIndex >= 0 {
+			return sizeWidened && expectedIndex > actualIndex
+		}
+	}
+	return false
+}
+
+// columnTypes returns types of existing columns of current table
+func (scope *Scope) columnTypes() (columnTypes map[string]columnType, ok bool) {
+	var (
+		rows      *sql.Rows
+		err       error
+		tableName = scope.TableName()
+	)
+
+	columnTypes = map[string]columnType{}
+	switch scope.Dialect().GetName() {
+	case "postgres":
+		rows, err = scope.NewDB().Raw("SELECT column_name, data_type, udt_name, character_maximum_length, numeric_precision, numeric_scale, is_nullable, column_default FROM information_schema.columns WHERE table_schema = CURRENT_SCHEMA() AND table_name = ?", tableName).Rows()
+	case "mysql":
+		rows, err = scope.NewDB().Raw("SELECT COLUMN_NAME, DATA_TYPE, COLUMN_TYPE, CHARACTER_MAXIMUM_LENGTH, NUMERIC_PRECISION, NUMERIC_SCALE, IS_NULLABLE, COLUMN_DEFAULT FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", tableName).Rows()
+	case "mssql":
+		rows, err = scope.NewDB().Raw("SELECT COLUMN_NAME, DATA_TYPE, DATA_TYPE, CHARACTER_MAXIMUM_LENGTH, COALESCE(NUMERIC_PRECISION, DATETIME_PRECISION), NUMERIC_SCALE, IS_NULLABLE, COLUMN_DEFAULT FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_CATALOG = DB_NAME() AND TABLE_NAME = ?", tableName).Rows()
+	case "sqlite3":
+		rows, err = scope.NewDB().Raw(fmt.Sprintf("PRAGMA table_info(%v)", scope.QuotedTableName())).Rows()
+		if scope.Err(err) != nil {
+			return nil, false
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				cid, notNull, primaryKey int
+				name, sqlType            string
+				defaultValue             sql.NullString
+			)
+			if scope.Err(rows.Scan(&cid, &name, &sqlType, &notNull, &defaultValue, &primaryKey)) != nil {
+				return nil, false
+			}
+
+			ct := parseColumnType(sqlType)
+			ct.notNull, ct.position = notNull == 1, cid
+			ct.defaultValue, ct.hasDefault = defaultValue.String, defaultValue.Valid
+			columnTypes[name] = ct
+		}
+		return columnTypes, true
+	default:
+		return nil, false
+	}
+
+	if scope.Err(err) != nil {
+		return nil, false
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			name, dataType, udtName, isNullable string
+			size, precision, scale              sql.NullInt64
+			defaultValue                        sql.NullString
+		)
+		if scope.Err(rows.Scan(&name, &dataType, &udtName, &size, &precision, &scale, &isNullable, &defaultValue)) != nil {
+			return nil, false
+		}
+
+		dataType = strings.ToLower(dataType)
+		if dataType == "user-defined" || dataType == "array" {
+			dataType = udtName
+		}
+
+		ct := parseColumnType(dataType)
+		ct.sqlType = udtName
+		if sizedColumnTypes[ct.name] && size.Int64 > 0 {
+			ct.size = size.Int64
+		}
+		if ct.name == "numeric" {
+			ct.precision, ct.scale = precision.Int64, scale.Int64
+		} else if scope.Dialect().GetName() == "mssql" && fractionalSecondsColumnTypes[dataType] {
+			ct.precision = precision.Int64
+		}
+		ct.notNull = strings.ToUpper(isNullable) == "NO"
+		ct.defaultValue, ct.hasDefault = defaultValue.String, defaultValue.Valid
+		columnTypes[name] = ct
+	}
+	return columnTypes, true
+}
+
+// expectedColumnType returns the column type of field declared by the model
+func (scope *Scope) expectedColumnType(field *StructField) columnType {
+	var (
+		dataType                = scope.Dialect().DataTypeOf(field)
+		_, _, _, additionalType = ParseFieldStructForDialect(field, scope.Dialect())
+	)
+
+	ct := parseColumnType(strings.TrimSuffix(dataType, " "+additionalType))
+	_, ct.notNull = field.TagSettingsGet("NOT NULL")
+	ct.defaultValue, ct.hasDefault = field.TagSettingsGet("DEFAULT")
+	return ct
+}
+
+// columnChange changes of an existing column to the definition of model
+type columnChange struct {
+	expected, actual                         columnType
+	typeChanged, nullChanged, defaultChanged bool
+	typeNarrowed, nullNarrowed               bool // narrowing changes skipped in safe mode
+}
+
+// narrowed returns true if any narrowing change of the column is skipped
+func (change columnChange) narrowed() bool {
+	return change.typeNarrowed || change.nullNarrowed
+}
+
+// keptDefinition returns the column definition with the changes applied, the type or nullability of skipped narrowing changes are kept
+func (change columnChange) keptDefinition() string {
+	sqlType := change.actual.sqlType
+	if change.typeChanged {
+		sqlType = change.expected.sqlType
+	}
+	if (change.nullChanged && change.expected.notNull) || (!change.nullChanged && change.actual.notNull) {
+		sqlType += " NOT NULL"
+	}
+	if change.expected.hasDefault {
+		sqlType += " DEFAULT " + change.expected.defaultValue
+	}
+	return sqlType
+}
+
+// alterableField returns true if the column of field is altered by alterColumns, primary keys and enums are skipped
+func alterableField(field *StructField) bool {
+	if !field.IsNormal || field.IsIgnored || field.IsPrimaryKey {
+		return false
+	}
+
+	if _, ok := field.TagSettingsGet("AUTO_INCREMENT"); ok {
+		return false
+	}
+
+	// enums are migrated with their types or constraints
+	_, ok := field.enumValues()
+	return !ok
+}
+
+// columnChangeOf compares the actual column with the definition of field, narrowing changes are skipped with setting `gorm:safe_migration`
+func (scope *Scope) columnChangeOf(field *StructField, actual columnType) columnChange {
+	expected := scope.expectedColumnType(field)
+	change := columnChange{
+		expected:       expected,
+		actual:         actual,
+		typeChanged:    !strings.HasSuffix(expected.name, "[]") && expected.typeChanged(actual),
+		nullChanged:    expected.notNull != actual.notNull,
+		defaultChanged: expected.hasDefault && (!actual.hasDefault || normalizeDefaultValue(expected.defaultValue) != normalizeDefaultValue(actual.defaultValue)),
+	}
+
+	if safeMode, _ := scope.Get("gorm:safe_migration"); safeMode == true {
+		if change.typeChanged && !expected.widens(actual) {
+			change.typeChanged, change.typeNarrowed = false, true
+		}
+
+		if change.nullChanged && expected.notNull {
+			change.nullChanged, change.nullNarrowed = false, true
+		}
+	}
+	return change
+}
+
+// keptColumnDefinitions returns definitions of columns having skipped narrowing changes, which are used instead of the model's when rebuilding sqlite tables
+func (scope *Scope) keptColumnDefinitions(actualTypes map[string]columnType) map[string]string {
+	definitions := map[string]string{}
+	for _, field := range scope.GetModelStruct().StructFields {
+		if actual, ok := actualTypes[field.DBName]; ok && alterableField(field) {
+			if change := scope.columnChangeOf(field, actual); change.narrowed() {
+				definitions[field.DBName] = change.keptDefinition()
+			}
+		}
+	}
+	return definitions
+}
+
+// alterColumns alters types, nullability and default values of existing columns to the definition of model,
+// changes narrowing columns are skipped with setting `gorm:safe_migration`
+func (scope *Scope) alterColumns() {
+	actualTypes, ok := scope.columnTypes()
+	if !ok {
+		return
+	}
+
+	var (
+		quotedTableName = scope.QuotedTableName()
+		rebuild         bool
+	)
+
+	for _, field := range scope.GetModelStruct().StructFields {
+		if !alterableField(field) {
+			continue
+		}
+
+		actual, ok := actualTypes[field.DBName]
+		if !ok {
+			continue
+		}
+
+		var (
+			change   = scope.columnChangeOf(field, actual)
+			expected = change.expected
+		)
+
+		if change.typeNarrowed {
+			scope.db.log(fmt.Sprintf("skip narrowing column %v.%v from %v to %v", scope.TableName(), field.DBName, actual.sqlType, expected.sqlType))
+		}
+
+		if change.nullNarrowed {
+			scope.db.log(fmt.Sprintf("skip adding NOT NULL to column %v.%v", scope.TableName(), field.DBName))
+		}
+
+		if !change.typeChanged && !change.nullChanged && !change.defaultChanged {
+			continue
+		}
+
+		quotedColumnName := scope.Quote(field.DBName)
+		switch scope.Dialect().GetName() {
+		case "sqlite3":
+			rebuild = true
+		case "mysql":
+			if change.narrowed() {
+				scope.modifyColumn(field.DBName, change.keptDefinition())
+			} else {
+				scope.modifyColumn(field.DBName, scope.Dialect().DataTypeOf(field))
+			}
+		case "postgres":
+			if change.typeChanged {
+				scope.Err(scope.NewDB().Exec(fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v TYPE %v USING %v::%v", quotedTableName, quotedColumnName, expected.sqlType, quotedColumnName, expected.sqlType)).Error)
+			}
+
+			if change.nullChanged {
+				if expected.notNull {
+					scope.Err(scope.NewDB().Exec(fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v SET NOT NULL", quotedTableName, quotedColumnName)).Error)
+				} else {
+					scope.Err(scope.NewDB().Exec(fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v DROP NOT NULL", quotedTableName, quotedColumnName)).Error)
+				}
+			}
+
+			if change.defaultChanged {
+				scope.Err(scope.NewDB().Exec(fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v SET DEFAULT %v", quotedTableName, quotedColumnName, expected.defaultValue)).Error)
+			}
+		case "mssql":
+			if change.typeChanged || change.nullChanged {
+				notNull := "NULL"
+				if (change.nullChanged && expected.notNull) || (!change.nullChanged && actual.notNull) {
+					notNull = "NOT NULL"
+				}
+
+				sqlType := expected.sqlType
+				if !change.typeChanged {
+					sqlType = actual.mssqlSQLType()
+				}
+				scope.Err(scope.NewDB().Exec(fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v %v %v", quotedTableName, quotedColumnName, sqlType, notNull)).Error)
+			}
+
+			if change.defaultChanged {
+				var constraintName string
+				if err := scope.NewDB().Raw("SELECT dc.name FROM sys.default_constraints dc JOIN sys.columns c ON c.object_id = dc.parent_object_id AND c.column_id = dc.parent_column_id WHERE dc.parent_object_id = OBJECT_ID(?) AND c.name = ?", scope.TableName(), field.DBName).Row().Scan(&constraintName); err != nil && err != sql.ErrNoRows {
+					scope.Err(err)
+					continue
+				}
+				if constraintName != "" {
+					scope.Err(scope.NewDB().Exec(fmt.Sprintf("ALTER TABLE %v DROP CONSTRAINT %v", quotedTableName, scope.Quote(constraintName))).Error)
+				}
+				scope.Err(scope.NewDB().Exec(fmt.Sprintf("ALTER TABLE %v ADD DEFAULT %v FOR %v", quotedTableName, expected.defaultValue, quotedColumnName)).Error)
+			}
+		}
+	}
+
+	// sqlite tables are rebuilt with the definition of model, columns having skipped narrowing changes keep their types and nullability
+	if rebuild {
+		scope.rebuildTable(scope.checks())
+	}
+}
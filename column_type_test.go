@@ -0,0 +1,222 @@
+package gorm_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+)
+
+type AlterColumnUser struct {
+	ID   uint
+	Name string `gorm:"size:20;index"`
+	Age  int
+	Code string `gorm:"size:10"`
+}
+
+type AlterColumnUserWidened struct {
+	ID   uint
+	Name string `gorm:"size:1024;index;default:'anonymous'"`
+	Age  int64
+	Code string `gorm:"size:10"`
+}
+
+func (AlterColumnUserWidened) TableName() string {
+	return "alter_column_users"
+}
+
+type AlterColumnUserNarrowed struct {
+	ID   uint
+	Name string `gorm:"size:1024;index;default:'anonymous'"`
+	Age  int64
+	Code string `gorm:"size:5;not null"`
+}
+
+func (AlterColumnUserNarrowed) TableName() string {
+	return "alter_column_users"
+}
+
+type AlterColumnUserMixed struct {
+	ID   uint
+	Name string `gorm:"size:1024;index"`
+	Age  int
+	Code string `gorm:"size:5;not null"`
+}
+
+func (AlterColumnUserMixed) TableName() string {
+	return "alter_column_users"
+}
+
+type AlterColumnParent struct {
+	ID   uint
+	Name string `gorm:"size:20"`
+}
+
+type AlterColumnParentWidened struct {
+	ID   uint
+	Name string `gorm:"size:100"`
+}
+
+func (AlterColumnParentWidened) TableName() string {
+	return "alter_column_parents"
+}
+
+type sqliteColumn struct {
+	Type         string
+	NotNull      bool
+	DefaultValue *string
+}
+
+func sqliteColumns(t *testing.T, table string) map[string]sqliteColumn {
+	rows, err := DB.Raw("PRAGMA table_info(" + table + ")").Rows()
+	if err != nil {
+		t.Fatalf("Failed to get table info, got %v", err)
+	}
+	defer rows.Close()
+
+	columns := map[string]sqliteColumn{}
+	for rows.Next() {
+		var (
+			cid, pk int
+			name    string
+			column  sqliteColumn
+		)
+		rows.Scan(&cid, &name, &column.Type, &column.NotNull, &column.DefaultValue, &pk)
+		columns[name] = column
+	}
+	return columns
+}
+
+func TestAutoMigrateAlterColumns(t *testing.T) {
+	if DB.Dialect().GetName() != "sqlite3" {
+		t.Skip("Skipping this because only sqlite's table info is checked")
+	}
+
+	DB.DropTableIfExists(&AlterColumnUser{})
+	if err := DB.AutoMigrate(&AlterColumnUser{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate, got %v", err)
+	}
+	DB.Exec("ALTER TABLE alter_column_users ADD extra varchar(10) DEFAULT 'x'")
+	DB.Save(&AlterColumnUser{Name: "alter", Age: 18, Code: "1234567890"})
+	DB.Exec("UPDATE alter_column_users SET extra = ?", "extra")
+
+	if err := DB.Set("gorm:safe_migration", true).AutoMigrate(&AlterColumnUserWidened{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate widened columns, got %v", err)
+	}
+
+	columns := sqliteColumns(t, "alter_column_users")
+	if columns["name"].Type != "varchar(1024)" || columns["name"].DefaultValue == nil || *columns["name"].DefaultValue != "'anonymous'" || columns["age"].Type != "bigint" {
+		t.Errorf("Should widen columns in safe mode, but got %+v, %+v", columns["name"], columns["age"])
+	}
+
+	if columns["extra"].Type != "varchar(10)" || *columns["extra"].DefaultValue != "'x'" {
+		t.Errorf("Should keep columns not declared by model, but got %+v", columns["extra"])
+	}
+
+	if err := DB.Set("gorm:safe_migration", true).AutoMigrate(&AlterColumnUserNarrowed{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate narrowed columns in safe mode, got %v", err)
+	}
+
+	if columns = sqliteColumns(t, "alter_column_users"); columns["code"].Type != "varchar(10)" || columns["code"].NotNull {
+		t.Errorf("Should not narrow columns in safe mode, but got %+v", columns["code"])
+	}
+
+	if err := DB.AutoMigrate(&AlterColumnUserNarrowed{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate narrowed columns, got %v", err)
+	}
+
+	if columns = sqliteColumns(t, "alter_column_users"); columns["code"].Type != "varchar(5)" || !columns["code"].NotNull {
+		t.Errorf("Should narrow columns without safe mode, but got %+v", columns["code"])
+	}
+
+	var result struct {
+		Name  string
+		Age   int64
+		Code  string
+		Extra string
+	}
+	if DB.Table("alter_column_users").Select("name, age, code, extra").Scan(&result); result.Name != "alter" || result.Age != 18 || result.Code != "1234567890" || result.Extra != "extra" {
+		t.Errorf("Existing records should be kept after altering columns, but got %+v", result)
+	}
+
+	if !DB.Dialect().HasIndex("alter_column_users", "idx_alter_column_users_name") {
+		t.Errorf("Indexes should be kept after altering columns")
+	}
+}
+
+func TestAutoMigrateWidenWithSkippedNarrowing(t *testing.T) {
+	if DB.Dialect().GetName() != "sqlite3" {
+		t.Skip("Skipping this because only sqlite's table info is checked")
+	}
+
+	DB.DropTableIfExists(&AlterColumnUser{})
+	if err := DB.AutoMigrate(&AlterColumnUser{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate, got %v", err)
+	}
+	DB.Save(&AlterColumnUser{Name: "mixed", Age: 20, Code: "1234567890"})
+
+	if err := DB.Set("gorm:safe_migration", true).AutoMigrate(&AlterColumnUserMixed{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate mixed columns in safe mode, got %v", err)
+	}
+
+	columns := sqliteColumns(t, "alter_column_users")
+	if columns["name"].Type != "varchar(1024)" {
+		t.Errorf("Should widen columns when narrowing columns are skipped, but got %+v", columns["name"])
+	}
+
+	if columns["code"].Type != "varchar(10)" || columns["code"].NotNull {
+		t.Errorf("Should not narrow columns in safe mode, but got %+v", columns["code"])
+	}
+
+	var result AlterColumnUser
+	if DB.Table("alter_column_users").Where("name = ?", "mixed").First(&result); result.Age != 20 || result.Code != "1234567890" {
+		t.Errorf("Existing records should be kept after altering columns, but got %+v", result)
+	}
+}
+
+func TestAutoMigrateAlterColumnsWithForeignKeys(t *testing.T) {
+	if DB.Dialect().GetName() != "sqlite3" {
+		t.Skip("Skipping this because only sqlite rebuilds tables")
+	}
+
+	db, err := gorm.Open("sqlite3", filepath.Join(os.TempDir(), "gorm_foreign_keys.db")+"?_foreign_keys=1")
+	if err != nil {
+		t.Fatalf("Failed to open database, got %v", err)
+	}
+	defer db.Close()
+
+	db.Exec("DROP TABLE IF EXISTS alter_column_children")
+	db.DropTableIfExists(&AlterColumnParent{})
+	db.AutoMigrate(&AlterColumnParent{})
+	db.Exec("CREATE TABLE alter_column_children (id integer primary key, parent_id integer REFERENCES alter_column_parents(id) ON DELETE CASCADE)")
+	db.Exec("CREATE INDEX idx_alter_column_parents_custom ON alter_column_parents(name, id)")
+
+	parent := AlterColumnParent{Name: "parent"}
+	db.Save(&parent)
+	db.Exec("INSERT INTO alter_column_children (parent_id) VALUES (?)", parent.ID)
+
+	if err := db.AutoMigrate(&AlterColumnParentWidened{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate with foreign keys, got %v", err)
+	}
+
+	var count int
+	if db.Table("alter_column_children").Where("parent_id = ?", parent.ID).Count(&count); count != 1 {
+		t.Errorf("Rows referencing the rebuilt table should be kept, but got %v", count)
+	}
+
+	if !db.Dialect().HasIndex("alter_column_parents", "idx_alter_column_parents_custom") {
+		t.Errorf("Indexes not declared by model should be kept after rebuilding table")
+	}
+
+	var foreignKeys bool
+	if db.Raw("PRAGMA foreign_keys").Row().Scan(&foreignKeys); !foreignKeys {
+		t.Errorf("Foreign keys should be enabled again after rebuilding table")
+	}
+
+	tx := db.Begin()
+	defer tx.Rollback()
+	if err := tx.AutoMigrate(&AlterColumnParent{}).Error; err == nil {
+		t.Errorf("Should return error when rebuilding table with foreign keys enabled in transaction")
+	}
+}
@@ -270,6 +270,31 @@ func TestOmitWithCreate(t *testing.T) {
 	}
 }
 
+func TestCreateWithMap(t *testing.T) {
+	if err := DB.Model(&User{}).Create(map[string]interface{}{"name": "create_with_map", "age": 18}).Error; err != nil {
+		t.Fatalf("Should create with map and model, but got %v", err)
+	}
+
+	if err := DB.Table("users").Create(&map[string]interface{}{"name": "create_with_map", "age": 20}).Error; err != nil {
+		t.Fatalf("Should create with map and table, but got %v", err)
+	}
+
+	var users []User
+	DB.Where("name = ?", "create_with_map").Order("age").Find(&users)
+	if len(users) != 2 || users[0].Age != 18 || users[1].Age != 20 {
+		t.Errorf("Should find users created with map, but got %v", len(users))
+	}
+
+	if err := DB.Table("users").Where("name = ?", "create_with_map").Updates(map[string]interface{}{"age": 30}).Error; err != nil {
+		t.Errorf("Should update with map and table, but got %v", err)
+	}
+
+	var count int
+	if DB.Model(&User{}).Where("name = ? AND age = ?", "create_with_map", 30).Count(&count); count != 2 {
+		t.Errorf("Should update users with map, but got %v", count)
+	}
+}
+
 func TestCreateIgnore(t *testing.T) {
 	float := 35.03554004971999
 	now := time.Now()
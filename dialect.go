@@ -94,6 +94,12 @@ var ParseFieldStructForDialect = func(field *StructField, dialect Dialect) (fiel
 		dataType, _ = field.TagSettingsGet("TYPE")
 	)
 
+	// serialized fields are saved as the data type of their serializers
+	serializer, isSerialized := field.serializer()
+	if isSerialized {
+		reflectType = reflect.TypeOf(serializer.DataType())
+	}
+
 	for reflectType.Kind() == reflect.Ptr {
 		reflectType = reflectType.Elem()
 	}
@@ -122,6 +128,9 @@ var ParseFieldStructForDialect = func(field *StructField, dialect Dialect) (fiel
 	// Default Size
 	if num, ok := field.TagSettingsGet("SIZE"); ok {
 		size, _ = strconv.Atoi(num)
+	} else if _, ok := field.TagSettingsGet("ENCRYPTED"); ok || isSerialized {
+		// encrypted or serialized values are longer than their plaintext, use unlimited size
+		size = 0
 	} else {
 		size = 255
 	}
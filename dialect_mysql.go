@@ -41,6 +41,10 @@ func (s *mysql) DataTypeOf(field *StructField) string {
 		}
 	}
 
+	if values, ok := field.enumValues(); ok && sqlType == "" {
+		sqlType = fmt.Sprintf("ENUM(%v)", quoteEnumValues(values))
+	}
+
 	if sqlType == "" {
 		switch dataValue.Kind() {
 		case reflect.Bool:
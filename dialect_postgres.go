@@ -1,6 +1,8 @@
 package gorm
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +15,20 @@ type postgres struct {
 	commonDialect
 }
 
+// PostgresArray wraps slices as postgres arrays, it is set to lib/pq's `pq.Array` when importing `github.com/jinzhu/gorm/dialects/postgres`
+var PostgresArray func(a interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}
+
+// postgresArrayTypes slices saved as postgres arrays
+var postgresArrayTypes = map[reflect.Type]string{
+	reflect.TypeOf([]string{}):  "text[]",
+	reflect.TypeOf([]int64{}):   "bigint[]",
+	reflect.TypeOf([]float64{}): "double precision[]",
+	reflect.TypeOf([]bool{}):    "boolean[]",
+}
+
 func init() {
 	RegisterDialect("postgres", &postgres{})
 	RegisterDialect("cloudsqlpostgres", &postgres{})
@@ -29,6 +45,10 @@ func (postgres) BindVar(i int) string {
 func (s *postgres) DataTypeOf(field *StructField) string {
 	var dataValue, sqlType, size, additionalType = ParseFieldStructForDialect(field, s)
 
+	if _, ok := field.enumValues(); ok && sqlType == "" {
+		sqlType = s.Quote(enumTypeName(field))
+	}
+
 	if sqlType == "" {
 		switch dataValue.Kind() {
 		case reflect.Bool:
@@ -68,7 +88,9 @@ func (s *postgres) DataTypeOf(field *StructField) string {
 				sqlType = "hstore"
 			}
 		default:
-			if IsByteArrayOrSlice(dataValue) {
+			if arrayType, ok := postgresArrayTypes[dataValue.Type()]; ok {
+				sqlType = arrayType
+			} else if IsByteArrayOrSlice(dataValue) {
 				sqlType = "bytea"
 
 				if isUUID(dataValue) {
@@ -142,6 +164,26 @@ func (postgres) OnConflict(values ...interface{}) (string, string, interface{})
 	return "", fmt.Sprintf("ON CONFLICT ON CONSTRAINT %s DO UPDATE SET %%v", values[0].(string)), values[1]
 }
 
+// postgresArray wraps slices or pointers of slices as postgres arrays when using postgres
+func (scope *Scope) postgresArray(value interface{}) (interface {
+	driver.Valuer
+	sql.Scanner
+}, bool) {
+	if PostgresArray == nil || value == nil || scope.Dialect().GetName() != "postgres" {
+		return nil, false
+	}
+
+	reflectType := reflect.TypeOf(value)
+	if reflectType.Kind() == reflect.Ptr {
+		reflectType = reflectType.Elem()
+	}
+
+	if _, ok := postgresArrayTypes[reflectType]; ok {
+		return PostgresArray(value), true
+	}
+	return nil, false
+}
+
 func isUUID(value reflect.Value) bool {
 	if value.Kind() != reflect.Array || value.Type().Len() != 16 {
 		return false
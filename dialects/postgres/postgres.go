@@ -8,10 +8,15 @@ import (
 	"errors"
 	"fmt"
 
-	_ "github.com/lib/pq"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
 	"github.com/lib/pq/hstore"
 )
 
+func init() {
+	gorm.PostgresArray = pq.Array
+}
+
 type Hstore map[string]*string
 
 // Value get value of Hstore
@@ -0,0 +1,384 @@
+package gorm
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// EncryptionKeyProvider provides keys for fields tagged with `encrypted`, values are encrypted with the current key,
+// and decrypted with the key of the id stored along with the value, so keys could be rotated by changing the current key
+//     type User struct {
+//       ID        uint
+//       Email     string `gorm:"encrypted;blind_index:EmailHash"`
+//       EmailHash string `gorm:"index"`
+//     }
+//
+//     db.SetEncryptionKeyProvider(gorm.NewStaticKeyProvider("v2", map[string][]byte{"v1": key1, "v2": key2}, hashKey))
+type EncryptionKeyProvider interface {
+	// CurrentKey returns the key used to encrypt values and its id
+	CurrentKey() (id string, key []byte, err error)
+	// Key returns the key of the id, used to decrypt values
+	Key(id string) ([]byte, error)
+	// BlindIndexKey returns the key to hash values into blind indexes, which shouldn't be rotated
+	BlindIndexKey() ([]byte, error)
+}
+
+// NewStaticKeyProvider returns an EncryptionKeyProvider with fixed AES keys (16, 24 or 32 bytes)
+func NewStaticKeyProvider(currentKeyID string, keys map[string][]byte, blindIndexKey []byte) EncryptionKeyProvider {
+	return &staticKeyProvider{currentKeyID: currentKeyID, keys: keys, blindIndexKey: blindIndexKey}
+}
+
+type staticKeyProvider struct {
+	currentKeyID  string
+	keys          map[string][]byte
+	blindIndexKey []byte
+}
+
+func (provider *staticKeyProvider) CurrentKey() (string, []byte, error) {
+	key, err := provider.Key(provider.currentKeyID)
+	return provider.currentKeyID, key, err
+}
+
+func (provider *staticKeyProvider) Key(id string) ([]byte, error) {
+	if key, ok := provider.keys[id]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("encryption key %v not found", id)
+}
+
+func (provider *staticKeyProvider) BlindIndexKey() ([]byte, error) {
+	if len(provider.blindIndexKey) == 0 {
+		return nil, errors.New("blind index key not found")
+	}
+	return provider.blindIndexKey, nil
+}
+
+// BlindIndex returns the blind index of the value, which could be used to query encrypted fields by equality
+//     hash, err := db.BlindIndex("jinzhu@example.org")
+//     db.Where("email_hash = ?", hash).First(&user)
+func (s *DB) BlindIndex(value interface{}) (string, error) {
+	return s.NewScope(nil).blindIndex(value)
+}
+
+func (scope *Scope) encryptionKeyProvider() (EncryptionKeyProvider, error) {
+	if provider := scope.db.parent.encryptionKeyProvider; provider != nil {
+		return provider, nil
+	}
+	return nil, ErrMissingEncryptionKeyProvider
+}
+
+func isEncryptedField(field *StructField) bool {
+	_, ok := field.TagSettingsGet("ENCRYPTED")
+	return ok
+}
+
+// comparedColumnRegexp matches columns compared in string conditions, like `email = ?`, `users.email IN (?)` or `"email" LIKE ?`
+var comparedColumnRegexp = regexp.MustCompile("(?i)(?:^|[^\\w.])(?:[`\"\\[]?\\w+[`\"\\]]?\\.)?[`\"\\[]?(\\w+)[`\"\\]]?\\s*(?:=|<>|!=|<|>|\\bIN\\b|\\bLIKE\\b|\\bNOT\\s+(?:IN|LIKE)\\b)")
+
+// checkEncryptedColumnCondition reports an error if the string condition compares encrypted columns, which are stored as ciphertext,
+// so they would never match, struct or map conditions should be used to query them with blind indexes
+func (scope *Scope) checkEncryptedColumnCondition(condition string) bool {
+	encryptedFields := map[string]*StructField{}
+	for _, field := range scope.GetModelStruct().StructFields {
+		if isEncryptedField(field) {
+			encryptedFields[strings.ToLower(field.DBName)] = field
+		}
+	}
+	if len(encryptedFields) == 0 {
+		return true
+	}
+
+	var columns []string
+	if columnRegexp.MatchString(condition) {
+		columns = append(columns, condition[strings.LastIndex(condition, ".")+1:])
+	}
+	for _, matches := range comparedColumnRegexp.FindAllStringSubmatch(condition, -1) {
+		columns = append(columns, matches[1])
+	}
+
+	for _, column := range columns {
+		if field, ok := encryptedFields[strings.ToLower(column)]; ok {
+			scope.Err(fmt.Errorf("can't query encrypted field %v with sql string, use struct or map conditions to query it by blind index", field.Name))
+			return false
+		}
+	}
+	return true
+}
+
+// blindIndexField returns the field to store blind index of the encrypted field, which is set with tag `blind_index`
+func (scope *Scope) blindIndexField(field *Field) (*Field, bool) {
+	if name, ok := field.TagSettingsGet("BLIND_INDEX"); ok {
+		return scope.FieldByName(name)
+	}
+	return nil, false
+}
+
+// plaintextOf returns the bytes of string, string pointer or bytes value, reports false if the value is nil
+func plaintextOf(value interface{}) ([]byte, bool, error) {
+	reflectValue := reflect.ValueOf(value)
+	for reflectValue.Kind() == reflect.Ptr {
+		if reflectValue.IsNil() {
+			return nil, false, nil
+		}
+		reflectValue = reflectValue.Elem()
+	}
+
+	switch {
+	case reflectValue.Kind() == reflect.String:
+		return []byte(reflectValue.String()), true, nil
+	case reflectValue.Kind() == reflect.Slice && reflectValue.Type().Elem().Kind() == reflect.Uint8:
+		if reflectValue.IsNil() {
+			return nil, false, nil
+		}
+		return reflectValue.Bytes(), true, nil
+	}
+	return nil, false, fmt.Errorf("unsupported value %v for encrypted field, should be string or bytes", value)
+}
+
+// encrypt encrypts the plaintext with AES-GCM, returns the id of the key and the base64 encoded nonce and ciphertext, like `v1:base64`
+func (scope *Scope) encrypt(plaintext []byte) (string, error) {
+	provider, err := scope.encryptionKeyProvider()
+	if err != nil {
+		return "", err
+	}
+
+	keyID, key, err := provider.CurrentKey()
+	if err != nil {
+		return "", err
+	}
+
+	if strings.Contains(keyID, ":") {
+		return "", fmt.Errorf("invalid encryption key id %v", keyID)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return keyID + ":" + base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+func (scope *Scope) decrypt(ciphertext string) ([]byte, error) {
+	provider, err := scope.encryptionKeyProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := strings.Index(ciphertext, ":")
+	if idx < 0 {
+		return nil, errors.New("invalid encrypted value")
+	}
+
+	key, err := provider.Key(ciphertext[:idx])
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(ciphertext[idx+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("invalid encrypted value")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// blindIndex returns the hex encoded HMAC-SHA256 of the value
+func (scope *Scope) blindIndex(value interface{}) (string, error) {
+	plaintext, _, err := plaintextOf(value)
+	if err != nil {
+		return "", err
+	}
+
+	provider, err := scope.encryptionKeyProvider()
+	if err != nil {
+		return "", err
+	}
+
+	key, err := provider.BlindIndexKey()
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(plaintext)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// blindIndexCondition returns the condition querying the encrypted field with its blind index, as encrypted values can't be compared
+func (scope *Scope) blindIndexCondition(fieldScope *Scope, field *Field, value interface{}, quotedTableName, equalSQL string) (string, bool) {
+	blindIndexField, ok := fieldScope.blindIndexField(field)
+	if !ok {
+		scope.Err(fmt.Errorf("can't query encrypted field %v without blind index", field.Name))
+		return "", false
+	}
+
+	blindIndex, err := scope.blindIndex(value)
+	if scope.Err(err) != nil {
+		return "", false
+	}
+	return fmt.Sprintf("(%v.%v %s %v)", quotedTableName, scope.Quote(blindIndexField.DBName), equalSQL, scope.AddToVars(blindIndex)), true
+}
+
+// encryptValue returns the encrypted value of the field, and its blind index if the field has blind index field, nil values are not encrypted
+func (scope *Scope) encryptValue(field *Field, value interface{}) (encrypted interface{}, blindIndex interface{}, err error) {
+	plaintext, ok, err := plaintextOf(value)
+	if err != nil || !ok {
+		return value, nil, err
+	}
+
+	if encrypted, err = scope.encrypt(plaintext); err == nil {
+		if _, ok := field.TagSettingsGet("BLIND_INDEX"); ok {
+			blindIndex, err = scope.blindIndex(plaintext)
+		}
+	}
+	return
+}
+
+// setFieldString set the string to field of string, string pointer or bytes
+func setFieldString(field *Field, str string) {
+	switch fieldType := field.Field.Type(); {
+	case fieldType.Kind() == reflect.Ptr:
+		value := reflect.New(fieldType.Elem())
+		value.Elem().Set(reflect.ValueOf(str).Convert(fieldType.Elem()))
+		field.Field.Set(value)
+	case fieldType.Kind() == reflect.String:
+		field.Field.Set(reflect.ValueOf(str).Convert(fieldType))
+	default:
+		field.Field.Set(reflect.ValueOf([]byte(str)).Convert(fieldType))
+	}
+}
+
+// decryptField decrypt the scanned value of encrypted field, blank values are kept as they are
+func (scope *Scope) decryptField(field *Field) error {
+	ciphertext, ok, err := plaintextOf(field.Field.Interface())
+	if err != nil || !ok || len(ciphertext) == 0 {
+		return err
+	}
+
+	plaintext, err := scope.decrypt(string(ciphertext))
+	if err == nil {
+		setFieldString(field, string(plaintext))
+	}
+	return err
+}
+
+// encryptFieldsCallback will encrypt values of encrypted fields before creating or updating, and set their blind indexes,
+// plaintext values are kept to restore them after saved
+func encryptFieldsCallback(scope *Scope) {
+	if scope.HasError() {
+		return
+	}
+
+	if values, ok := scope.Get("gorm:create_many"); ok {
+		var createMany []map[string]interface{}
+		for _, attrs := range values.([]map[string]interface{}) {
+			createMany = append(createMany, scope.encryptAttrs(attrs))
+		}
+		scope.Set("gorm:create_many", createMany)
+		return
+	}
+
+	if attrs, ok := scope.InstanceGet("gorm:update_attrs"); ok {
+		scope.InstanceSet("gorm:plaintext_update_attrs", attrs)
+		scope.InstanceSet("gorm:update_attrs", scope.encryptAttrs(attrs.(map[string]interface{})))
+		return
+	}
+
+	if scope.IndirectValue().Kind() != reflect.Struct {
+		return
+	}
+
+	plaintexts := map[*Field]reflect.Value{}
+	for _, field := range scope.Fields() {
+		if !field.IsNormal || !isEncryptedField(field.StructField) {
+			continue
+		}
+
+		encrypted, blindIndex, err := scope.encryptValue(field, field.Field.Interface())
+		if scope.Err(err) != nil {
+			break
+		}
+
+		if blindIndex != nil {
+			if blindIndexField, ok := scope.blindIndexField(field); ok {
+				scope.Err(blindIndexField.Set(blindIndex))
+			}
+		}
+
+		if str, ok := encrypted.(string); ok {
+			plaintext := reflect.New(field.Field.Type()).Elem()
+			plaintext.Set(field.Field)
+			plaintexts[field] = plaintext
+			setFieldString(field, str)
+		}
+	}
+	scope.InstanceSet("gorm:plaintext_fields", plaintexts)
+}
+
+// encryptAttrs returns a copy of attrs with encrypted values and blind indexes of encrypted columns
+func (scope *Scope) encryptAttrs(attrs map[string]interface{}) map[string]interface{} {
+	results := map[string]interface{}{}
+	for column, value := range attrs {
+		results[column] = value
+	}
+
+	for column, value := range attrs {
+		if field, ok := scope.FieldByName(column); ok && isEncryptedField(field.StructField) {
+			encrypted, blindIndex, err := scope.encryptValue(field, value)
+			if scope.Err(err) != nil {
+				break
+			}
+
+			results[column] = encrypted
+			if blindIndexField, ok := scope.blindIndexField(field); ok && blindIndex != nil {
+				results[blindIndexField.DBName] = blindIndex
+			}
+		}
+	}
+	return results
+}
+
+// restoreEncryptedFieldsCallback will restore plaintext values of encrypted fields after created or updated
+func restoreEncryptedFieldsCallback(scope *Scope) {
+	if attrs, ok := scope.InstanceGet("gorm:plaintext_update_attrs"); ok {
+		scope.InstanceSet("gorm:update_attrs", attrs)
+	}
+
+	if plaintexts, ok := scope.InstanceGet("gorm:plaintext_fields"); ok {
+		for field, plaintext := range plaintexts.(map[*Field]reflect.Value) {
+			field.Field.Set(plaintext)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package gorm_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+)
+
+type EncryptedUser struct {
+	ID        uint
+	Name      string
+	Email     string  `gorm:"encrypted;blind_index:EmailHash"`
+	EmailHash string  `gorm:"index"`
+	Phone     *string `gorm:"encrypted"`
+	Note      []byte  `gorm:"encrypted"`
+}
+
+func TestEncryptedFields(t *testing.T) {
+	var (
+		key1    = []byte("0123456789abcdef0123456789abcdef")
+		key2    = []byte("fedcba9876543210fedcba9876543210")
+		hashKey = []byte("blind index key")
+		phone   = "555-0100"
+	)
+	DB.SetEncryptionKeyProvider(gorm.NewStaticKeyProvider("v1", map[string][]byte{"v1": key1}, hashKey))
+	defer DB.SetEncryptionKeyProvider(nil)
+
+	DB.DropTableIfExists(&EncryptedUser{})
+	DB.AutoMigrate(&EncryptedUser{})
+
+	user := EncryptedUser{Name: "encrypted", Email: "encrypted@example.org", Phone: &phone, Note: []byte("note")}
+	if err := DB.Create(&user).Error; err != nil {
+		t.Fatalf("Should create record with encrypted fields, but got %v", err)
+	}
+
+	if user.Email != "encrypted@example.org" || *user.Phone != "555-0100" || phone != "555-0100" || string(user.Note) != "note" || user.EmailHash == "" {
+		t.Errorf("Plaintext values should be kept after creating, but got %v, %v, %v", user.Email, *user.Phone, string(user.Note))
+	}
+
+	var raw struct {
+		Email string
+		Phone string
+	}
+	DB.Raw("SELECT email, phone FROM encrypted_users WHERE id = ?", user.ID).Scan(&raw)
+	if !strings.HasPrefix(raw.Email, "v1:") || strings.Contains(raw.Email, "encrypted@example.org") || !strings.HasPrefix(raw.Phone, "v1:") {
+		t.Errorf("Values should be encrypted in database, but got %v, %v", raw.Email, raw.Phone)
+	}
+
+	var result EncryptedUser
+	if err := DB.First(&result, user.ID).Error; err != nil || result.Email != "encrypted@example.org" || *result.Phone != "555-0100" || string(result.Note) != "note" {
+		t.Errorf("Should decrypt values when querying, but got %v, %v", result.Email, err)
+	}
+
+	if err := DB.Where(&EncryptedUser{Email: "encrypted@example.org"}).First(&result).Error; err != nil || result.ID != user.ID {
+		t.Errorf("Should query encrypted fields with blind index, but got %v", err)
+	}
+
+	hash, _ := DB.BlindIndex("encrypted@example.org")
+	if err := DB.Where("email_hash = ?", hash).First(&result).Error; err != nil || result.ID != user.ID {
+		t.Errorf("Should query blind index column, but got %v", err)
+	}
+
+	if err := DB.Where(&EncryptedUser{Phone: &phone}).First(&result).Error; err == nil {
+		t.Errorf("Should return error when querying encrypted fields without blind index")
+	}
+
+	if err := DB.Where(map[string]interface{}{"email": "encrypted@example.org"}).First(&result).Error; err != nil || result.ID != user.ID {
+		t.Errorf("Should query encrypted fields of map conditions with blind index, but got %v", err)
+	}
+
+	if err := DB.Where(map[string]interface{}{"phone": phone}).First(&result).Error; err == nil {
+		t.Errorf("Should return error when querying encrypted fields of map conditions without blind index")
+	}
+
+	for _, condition := range []string{"email = ?", "encrypted_users.email IN (?)", `"email" LIKE ?`, "name = ? AND email <> ?"} {
+		if err := DB.Where(condition, "encrypted@example.org", "encrypted@example.org").First(&result).Error; err == nil || !strings.Contains(err.Error(), "encrypted field Email") {
+			t.Errorf("Should return error when querying encrypted fields with string condition %v, but got %v", condition, err)
+		}
+	}
+
+	if err := DB.Not("email", []string{"encrypted@example.org"}).First(&result).Error; err == nil {
+		t.Errorf("Should return error when querying encrypted fields with not conditions")
+	}
+
+	DB.Model(&user).Updates(map[string]interface{}{"email": "updated@example.org"})
+	if err := DB.Where(&EncryptedUser{Email: "updated@example.org"}).First(&result).Error; err != nil || result.Email != "updated@example.org" {
+		t.Errorf("Should encrypt values of updating maps, but got %v, %v", result.Email, err)
+	}
+
+	// rotate keys
+	DB.SetEncryptionKeyProvider(gorm.NewStaticKeyProvider("v2", map[string][]byte{"v1": key1, "v2": key2}, hashKey))
+
+	DB.CreateMany([]interface{}{
+		&EncryptedUser{Name: "many1", Email: "many1@example.org"},
+		&EncryptedUser{Name: "many2", Email: "many2@example.org"},
+	})
+	DB.Raw("SELECT email FROM encrypted_users WHERE name = ?", "many1").Scan(&raw)
+	if !strings.HasPrefix(raw.Email, "v2:") {
+		t.Errorf("Should encrypt values with the current key, but got %v", raw.Email)
+	}
+
+	var users []EncryptedUser
+	if DB.Order("id").Find(&users); len(users) != 3 || users[0].Email != "updated@example.org" || users[2].Email != "many2@example.org" {
+		t.Errorf("Should decrypt values encrypted with old and new keys, but got %v", users)
+	}
+
+	DB.SetEncryptionKeyProvider(nil)
+	if err := DB.First(&result, user.ID).Error; err != gorm.ErrMissingEncryptionKeyProvider {
+		t.Errorf("Should return error without key provider, but got %v", err)
+	}
+}
+
+type EncryptedNote struct {
+	ID   uint
+	Body string `gorm:"encrypted"`
+}
+
+func TestEncryptedFieldsWithoutBlindIndexKey(t *testing.T) {
+	DB.SetEncryptionKeyProvider(gorm.NewStaticKeyProvider("v1", map[string][]byte{"v1": []byte("0123456789abcdef")}, nil))
+	defer DB.SetEncryptionKeyProvider(nil)
+
+	DB.DropTableIfExists(&EncryptedNote{})
+	DB.AutoMigrate(&EncryptedNote{})
+
+	note := EncryptedNote{Body: "note"}
+	if err := DB.Create(&note).Error; err != nil {
+		t.Fatalf("Should create record without blind index key, but got %v", err)
+	}
+
+	if err := DB.Model(&note).Update("body", "updated").Error; err != nil {
+		t.Errorf("Should update record without blind index key, but got %v", err)
+	}
+
+	var result EncryptedNote
+	if err := DB.First(&result, note.ID).Error; err != nil || result.Body != "updated" {
+		t.Errorf("Should decrypt values without blind index key, but got %v, %v", result.Body, err)
+	}
+}
@@ -0,0 +1,160 @@
+package gorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Enum is implemented by types having a fixed set of values, which will be enforced by the database
+//     type Status string
+//
+//     func (Status) EnumValues() []string {
+//       return []string{"active", "suspended", "deleted"}
+//     }
+//
+// fields could also be declared as enums with tag `enum`
+//     type User struct {
+//       Status Status
+//       Role   string `gorm:"enum:admin,member"`
+//     }
+//
+// enums are saved as enum types with postgres, `ENUM` columns with mysql and columns with CHECK constraints with others
+type Enum interface {
+	EnumValues() []string
+}
+
+// enumValues returns the values of enum fields
+func (sf *StructField) enumValues() ([]string, bool) {
+	if str, ok := sf.TagSettingsGet("ENUM"); ok && str != "ENUM" && str != "" {
+		var values []string
+		for _, value := range strings.Split(str, ",") {
+			values = append(values, strings.TrimSpace(value))
+		}
+		return values, true
+	}
+
+	fieldType := sf.Struct.Type
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	if enum, ok := reflect.New(fieldType).Interface().(Enum); ok {
+		return enum.EnumValues(), true
+	}
+	return nil, false
+}
+
+// enumTypeName returns the name of postgres enum type for the field, it is the snake case name of the field's type,
+// or the column name with suffix `_enum` for fields of builtin types
+func enumTypeName(field *StructField) string {
+	fieldType := field.Struct.Type
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	if fieldType.PkgPath() != "" && fieldType.Name() != "" {
+		return ToColumnName(fieldType.Name())
+	}
+	return field.DBName + "_enum"
+}
+
+// quoteEnumValues quotes enum values as sql strings
+func quoteEnumValues(values []string) string {
+	var quoted []string
+	for _, value := range values {
+		quoted = append(quoted, "'"+strings.Replace(value, "'", "''", -1)+"'")
+	}
+	return strings.Join(quoted, ",")
+}
+
+// missingEnumValues returns values not included in the definition of existing enums
+func missingEnumValues(definition string, values []string) (missing []string) {
+	for _, value := range values {
+		if !strings.Contains(definition, "'"+strings.Replace(value, "'", "''", -1)+"'") {
+			missing = append(missing, value)
+		}
+	}
+	return
+}
+
+// enumChecks returns CHECK constraints of enum fields for databases don't support enum types, they are prefixed with `chk_enum`
+// so they won't conflict with CHECK constraints declared with tag `check` of the same field
+func (scope *Scope) enumChecks() (checks []checkConstraint) {
+	switch scope.Dialect().GetName() {
+	case "postgres", "mysql":
+		return nil
+	}
+
+	for _, field := range scope.GetModelStruct().StructFields {
+		if values, ok := field.enumValues(); ok && field.IsNormal && !field.IsIgnored {
+			checks = append(checks, checkConstraint{
+				name:       scope.Dialect().BuildKeyName("chk_enum", scope.TableName(), field.DBName),
+				expression: fmt.Sprintf("%v IN (%v)", scope.Quote(field.DBName), quoteEnumValues(values)),
+				enumValues: values,
+			})
+		}
+	}
+	return
+}
+
+// createEnumTypes creates postgres enum types for enum fields, new values will be added to existing types
+func (scope *Scope) createEnumTypes() {
+	if scope.Dialect().GetName() != "postgres" {
+		return
+	}
+
+	created := map[string]bool{}
+	for _, field := range scope.GetModelStruct().StructFields {
+		values, ok := field.enumValues()
+		if !ok || !field.IsNormal || field.IsIgnored {
+			continue
+		}
+
+		typeName := enumTypeName(field)
+		if created[typeName] {
+			continue
+		}
+		created[typeName] = true
+
+		var labels []string
+		rows, err := scope.NewDB().Raw("SELECT e.enumlabel FROM pg_enum e JOIN pg_type t ON t.oid = e.enumtypid JOIN pg_namespace n ON n.oid = t.typnamespace WHERE t.typname = ? AND n.nspname = CURRENT_SCHEMA()", typeName).Rows()
+		if scope.Err(err) != nil {
+			return
+		}
+		for rows.Next() {
+			var label string
+			if scope.Err(rows.Scan(&label)) == nil {
+				labels = append(labels, "'"+strings.Replace(label, "'", "''", -1)+"'")
+			}
+		}
+		rows.Close()
+
+		if len(labels) == 0 {
+			scope.Err(scope.NewDB().Exec(fmt.Sprintf("CREATE TYPE %v AS ENUM (%v)", scope.Quote(typeName), quoteEnumValues(values))).Error)
+			continue
+		}
+
+		for _, value := range missingEnumValues(strings.Join(labels, ","), values) {
+			scope.Err(scope.NewDB().Exec(fmt.Sprintf("ALTER TYPE %v ADD VALUE %v", scope.Quote(typeName), quoteEnumValues([]string{value}))).Error)
+		}
+	}
+}
+
+// migrateEnums extends mysql enum columns of existing tables when new values are added, postgres enum types are extended
+// before adding columns and CHECK constraints of other databases are replaced when migrating checks
+func (scope *Scope) migrateEnums() {
+	if scope.Dialect().GetName() != "mysql" {
+		return
+	}
+
+	for _, field := range scope.GetModelStruct().StructFields {
+		if values, ok := field.enumValues(); ok && field.IsNormal && !field.IsIgnored {
+			var columnType string
+			scope.NewDB().Raw("SELECT COLUMN_TYPE FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?", scope.TableName(), field.DBName).Row().Scan(&columnType)
+			if columnType != "" && len(missingEnumValues(columnType, values)) > 0 {
+				scope.modifyColumn(field.DBName, scope.Dialect().DataTypeOf(field))
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package gorm_test
+
+import (
+	"testing"
+)
+
+type EnumStatus string
+
+func (EnumStatus) EnumValues() []string {
+	return []string{"active", "suspended", "deleted"}
+}
+
+type EnumUser struct {
+	ID     uint
+	Name   string `gorm:"index"`
+	Status EnumStatus
+	Role   string `gorm:"enum:admin,member"`
+}
+
+type EnumUserWithGuest struct {
+	ID     uint
+	Name   string `gorm:"index"`
+	Status EnumStatus
+	Role   string `gorm:"enum:admin,member,guest"`
+}
+
+func (EnumUserWithGuest) TableName() string {
+	return "enum_users"
+}
+
+func TestEnum(t *testing.T) {
+	DB.DropTableIfExists(&EnumUser{})
+	if err := DB.AutoMigrate(&EnumUser{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate, got %v", err)
+	}
+
+	if err := DB.Save(&EnumUser{Name: "enum", Status: "active", Role: "admin"}).Error; err != nil {
+		t.Errorf("Should save enum values, but got %v", err)
+	}
+
+	if err := DB.Save(&EnumUser{Name: "enum", Status: "unknown", Role: "admin"}).Error; err == nil {
+		t.Errorf("Should not save values not in enum type")
+	}
+
+	if err := DB.Save(&EnumUser{Name: "enum", Status: "active", Role: "guest"}).Error; err == nil {
+		t.Errorf("Should not save values not in enum tag")
+	}
+
+	if err := DB.AutoMigrate(&EnumUserWithGuest{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate new enum values, got %v", err)
+	}
+
+	if err := DB.Save(&EnumUserWithGuest{Name: "enum", Status: "suspended", Role: "guest"}).Error; err != nil {
+		t.Errorf("Should save new enum values after auto migrating, but got %v", err)
+	}
+
+	var count int
+	if DB.Model(&EnumUserWithGuest{}).Where("name = ?", "enum").Count(&count); count != 2 {
+		t.Errorf("Existing records should be kept after auto migrating enums, but got %v", count)
+	}
+
+	if !DB.Dialect().HasIndex("enum_users", "idx_enum_users_name") {
+		t.Errorf("Indexes should be kept after auto migrating enums")
+	}
+
+	if err := DB.AutoMigrate(&EnumUserWithGuest{}).Error; err != nil {
+		t.Errorf("Should auto migrate migrated enums, but got %v", err)
+	}
+}
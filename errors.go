@@ -16,6 +16,8 @@ var (
 	ErrCantStartTransaction = errors.New("can't start transaction")
 	// ErrUnaddressable unaddressable value
 	ErrUnaddressable = errors.New("using unaddressable value")
+	// ErrMissingEncryptionKeyProvider occurs when saving or querying encrypted fields without setting the key provider with `SetEncryptionKeyProvider`
+	ErrMissingEncryptionKeyProvider = errors.New("missing encryption key provider")
 )
 
 // Errors contains all happened errors
@@ -30,6 +30,11 @@ func (field *Field) Set(value interface{}) (err error) {
 		reflectValue = reflect.ValueOf(value)
 	}
 
+	// values of serialized fields from database need to be deserialized
+	if serializer, ok := field.serializer(); ok && reflectValue.IsValid() && !reflectValue.Type().ConvertibleTo(field.Field.Type()) {
+		return field.deserialize(serializer, reflectValue.Interface())
+	}
+
 	fieldValue := field.Field
 	if reflectValue.IsValid() {
 		if reflectValue.Type().ConvertibleTo(fieldValue.Type()) {
@@ -0,0 +1,201 @@
+package gorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Historical is the interface for models which keep previous versions of rows in the `<table>_history` table,
+// tag any field with `gorm:"history"` could also enable it
+//     func (User) Historical() bool { return true }
+//
+//     db.AutoMigrate(&User{}) // creates tables `users` and `users_history`
+//     db.AsOf(lastWeek).Find(&users)
+type Historical interface {
+	Historical() bool
+}
+
+// historyPeriod defines the period columns of history tables, a version of row is valid from valid_from (inclusive) to valid_to (exclusive)
+type historyPeriod struct {
+	ValidFrom *time.Time
+	ValidTo   time.Time `gorm:"not null"`
+}
+
+// historical check if current model keeps history of rows
+func (scope *Scope) historical() bool {
+	modelType := scope.GetModelStruct().ModelType
+	if modelType == nil || modelType.Kind() != reflect.Struct {
+		return false
+	}
+
+	if historical, ok := reflect.New(modelType).Interface().(Historical); ok {
+		return historical.Historical()
+	}
+
+	// embedded structs are flattened, so check tags of the model type directly
+	for i := 0; i < modelType.NumField(); i++ {
+		if _, ok := parseTagSetting(modelType.Field(i).Tag)["HISTORY"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (scope *Scope) historyTableName() string {
+	return scope.TableName() + "_history"
+}
+
+// historyCreatedAtField returns field `CreatedAt`, the first version of rows is valid since it was created
+func (scope *Scope) historyCreatedAtField() *StructField {
+	for _, field := range scope.GetModelStruct().StructFields {
+		if field.Name == "CreatedAt" && field.IsNormal {
+			return field
+		}
+	}
+	return nil
+}
+
+// historyFields returns fields of history table, which are normal fields of the model without keys, and period fields
+func (scope *Scope) historyFields() (fields []*StructField) {
+	for _, field := range scope.GetModelStruct().StructFields {
+		if field.IsNormal {
+			field = field.clone()
+			field.IsPrimaryKey = false
+			field.TagSettingsDelete("AUTO_INCREMENT")
+			field.TagSettingsDelete("UNIQUE")
+			fields = append(fields, field)
+		}
+	}
+	return append(fields, scope.New(&historyPeriod{}).GetModelStruct().StructFields...)
+}
+
+// autoMigrateHistory create the history table or add missing columns to it
+func (scope *Scope) autoMigrateHistory() {
+	var (
+		tableName       = scope.historyTableName()
+		quotedTableName = scope.Quote(tableName)
+		hasTable        = scope.Dialect().HasTable(tableName)
+		columns         []string
+	)
+
+	for _, field := range scope.historyFields() {
+		column := scope.Quote(field.DBName) + " " + scope.Dialect().DataTypeOf(field)
+		if !hasTable {
+			columns = append(columns, column)
+		} else if !scope.Dialect().HasColumn(tableName, field.DBName) {
+			scope.Err(scope.NewDB().Exec(fmt.Sprintf("ALTER TABLE %v ADD %v", quotedTableName, column)).Error)
+		}
+	}
+
+	if !hasTable {
+		scope.Err(scope.NewDB().Exec(fmt.Sprintf("CREATE TABLE %v (%v)%s", quotedTableName, strings.Join(columns, ","), scope.getTableOptions())).Error)
+	}
+
+	var indexColumns []string
+	for _, field := range scope.GetModelStruct().PrimaryFields {
+		indexColumns = append(indexColumns, field.DBName)
+	}
+	indexColumns = append(indexColumns, "valid_to")
+
+	historyScope := scope.New(scope.Value)
+	historyScope.Search.Table(tableName)
+	historyScope.Search.Unscoped = true
+	historyScope.addIndex(false, scope.Dialect().BuildKeyName("idx", tableName, indexColumns...), indexColumns...)
+	scope.Err(historyScope.db.Error)
+}
+
+// saveHistory copy current versions of rows that will be updated or deleted into the history table
+func (scope *Scope) saveHistory() {
+	var (
+		historyScope           = scope.New(scope.Value)
+		quotedTableName        = scope.QuotedTableName()
+		quotedHistoryTableName = scope.Quote(scope.historyTableName())
+		columns, values        []string
+		primaryConditions      []string
+	)
+	historyScope.Search = scope.Search.clone()
+
+	for _, field := range scope.GetModelStruct().StructFields {
+		if field.IsNormal {
+			columns = append(columns, scope.Quote(field.DBName))
+			values = append(values, fmt.Sprintf("%v.%v", quotedTableName, scope.Quote(field.DBName)))
+		}
+	}
+
+	for _, field := range scope.GetModelStruct().PrimaryFields {
+		primaryConditions = append(primaryConditions, fmt.Sprintf("%v.%v = %v.%v", quotedHistoryTableName, scope.Quote(field.DBName), quotedTableName, scope.Quote(field.DBName)))
+	}
+
+	// previous version is valid from the end of its previous version, or the time it was created
+	validFrom := fmt.Sprintf("(SELECT MAX(%v.%v) FROM %v WHERE %v)", quotedHistoryTableName, scope.Quote("valid_to"), quotedHistoryTableName, strings.Join(primaryConditions, " AND "))
+	if field := scope.historyCreatedAtField(); field != nil {
+		validFrom = fmt.Sprintf("COALESCE(%v, %v.%v)", validFrom, quotedTableName, scope.Quote(field.DBName))
+	}
+
+	historyScope.Raw(fmt.Sprintf(
+		"INSERT INTO %v (%v, %v, %v) SELECT %v, %v, %v FROM %v%v",
+		quotedHistoryTableName,
+		strings.Join(columns, ","),
+		scope.Quote("valid_from"),
+		scope.Quote("valid_to"),
+		strings.Join(values, ","),
+		validFrom,
+		historyScope.AddToVars(scope.db.nowFunc()),
+		quotedTableName,
+		addExtraSpaceIfExist(historyScope.whereSQL()),
+	)).Exec()
+	scope.Err(historyScope.db.Error)
+}
+
+// asOfSQL returns sql of rows valid at the time, which are versions in the history table valid at the time,
+// and current rows created before the time and not changed after the time
+func (scope *Scope) asOfSQL(t time.Time) string {
+	var (
+		quotedTableName        = scope.QuotedTableName()
+		quotedHistoryTableName = scope.Quote(scope.historyTableName())
+		columns                []string
+		primaryConditions      []string
+	)
+
+	for _, field := range scope.GetModelStruct().StructFields {
+		if field.IsNormal {
+			columns = append(columns, scope.Quote(field.DBName))
+		}
+	}
+
+	for _, field := range scope.GetModelStruct().PrimaryFields {
+		primaryConditions = append(primaryConditions, fmt.Sprintf("%v.%v = %v.%v", quotedHistoryTableName, scope.Quote(field.DBName), quotedTableName, scope.Quote(field.DBName)))
+	}
+
+	sql := fmt.Sprintf(
+		"SELECT %v FROM %v WHERE (%v IS NULL OR %v <= %v) AND %v > %v UNION ALL SELECT %v FROM %v WHERE NOT EXISTS (SELECT 1 FROM %v WHERE %v AND %v.%v > %v)",
+		strings.Join(columns, ","),
+		quotedHistoryTableName,
+		scope.Quote("valid_from"),
+		scope.Quote("valid_from"),
+		scope.AddToVars(t),
+		scope.Quote("valid_to"),
+		scope.AddToVars(t),
+		strings.Join(columns, ","),
+		quotedTableName,
+		quotedHistoryTableName,
+		strings.Join(primaryConditions, " AND "),
+		quotedHistoryTableName,
+		scope.Quote("valid_to"),
+		scope.AddToVars(t),
+	)
+
+	if field := scope.historyCreatedAtField(); field != nil {
+		sql += fmt.Sprintf(" AND %v.%v <= %v", quotedTableName, scope.Quote(field.DBName), scope.AddToVars(t))
+	}
+	return sql
+}
+
+// saveHistoryCallback will copy current versions of rows into the history table before updating or deleting
+func saveHistoryCallback(scope *Scope) {
+	if !scope.HasError() && scope.historical() {
+		scope.saveHistory()
+	}
+}
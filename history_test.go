@@ -0,0 +1,70 @@
+package gorm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type HistoryUser struct {
+	gorm.Model `gorm:"history"`
+	Name       string
+	Age        int
+}
+
+func TestHistory(t *testing.T) {
+	DB.DropTableIfExists(&HistoryUser{}, "history_users_history")
+	if err := DB.AutoMigrate(&HistoryUser{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate, got %v", err)
+	}
+
+	if !DB.HasTable("history_users_history") || !DB.Dialect().HasColumn("history_users_history", "valid_from") || !DB.Dialect().HasColumn("history_users_history", "valid_to") {
+		t.Fatalf("Should create history table")
+	}
+
+	var times []time.Time
+	tick := func() {
+		time.Sleep(10 * time.Millisecond)
+		times = append(times, time.Now())
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tick()
+	user := HistoryUser{Name: "history", Age: 10}
+	DB.Save(&user)
+	tick()
+	DB.Model(&user).Update("age", 20)
+	tick()
+	user.Name = "history2"
+	DB.Save(&user)
+	tick()
+	DB.Delete(&user)
+	tick()
+
+	var count int
+	if DB.Table("history_users_history").Count(&count); count != 3 {
+		t.Errorf("Should save previous versions before updating and deleting, but got %v", count)
+	}
+
+	for idx, expected := range []*HistoryUser{nil, {Name: "history", Age: 10}, {Name: "history", Age: 20}, {Name: "history2", Age: 20}, nil} {
+		var users []HistoryUser
+		DB.AsOf(times[idx]).Where("id = ?", user.ID).Find(&users)
+
+		if expected == nil {
+			if len(users) != 0 {
+				t.Errorf("#%v: Should not find user, but got %v", idx, users)
+			}
+		} else if len(users) != 1 || users[0].Name != expected.Name || users[0].Age != expected.Age {
+			t.Errorf("#%v: Should find user as of the time, but got %v", idx, users)
+		}
+	}
+
+	if DB.AsOf(times[2]).Model(&HistoryUser{}).Where("age = ?", 20).Count(&count); count != 1 {
+		t.Errorf("Should count users as of the time, but got %v", count)
+	}
+
+	if DB.Model(&HistoryUser{}).Count(&count); count != 0 {
+		t.Errorf("Should query current rows without AsOf, but got %v", count)
+	}
+}
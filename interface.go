@@ -13,6 +13,13 @@ type SQLCommon interface {
 	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
+// sqlCommonContext is implemented by *sql.DB and *sql.Tx, used to execute sql with the context set with `db.WithContext`
+type sqlCommonContext interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 type sqlDb interface {
 	Begin() (*sql.Tx, error)
 	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
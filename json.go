@@ -0,0 +1,163 @@
+package gorm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm/clause"
+)
+
+// JSON is the json data type, saved as `jsonb` with postgres, `json` with mysql and text with others
+//     type User struct {
+//       Attrs gorm.JSON
+//     }
+type JSON json.RawMessage
+
+// Value return json value, implement driver.Valuer interface
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+// Scan scan value into JSON, implements sql.Scanner interface
+func (j *JSON) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+		return nil
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("failed to unmarshal JSON value: %v", value)
+	}
+
+	result := json.RawMessage{}
+	err := json.Unmarshal(bytes, &result)
+	*j = JSON(result)
+	return err
+}
+
+// MarshalJSON to output non base64 encoded []byte
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return json.RawMessage(j).MarshalJSON()
+}
+
+// UnmarshalJSON to deserialize []byte
+func (j *JSON) UnmarshalJSON(b []byte) error {
+	if j == nil {
+		return errors.New("gorm.JSON: UnmarshalJSON on nil pointer")
+	}
+	*j = append((*j)[0:0], b...)
+	return nil
+}
+
+func (j JSON) String() string {
+	return string(j)
+}
+
+// GormDataType returns the column type of JSON for the dialect
+func (JSON) GormDataType(dialect Dialect) string {
+	switch dialect.GetName() {
+	case "postgres":
+		return "jsonb"
+	case "mysql":
+		return "json"
+	case "mssql":
+		return "nvarchar(max)"
+	}
+	return "text"
+}
+
+// JSONQueryExpression json query conditions, could be used as conditions of `Where`
+//     db.Where(gorm.JSONQuery("attrs").HasKey("address", "city")).Find(&users)
+//     db.Where(gorm.JSONQuery("attrs").Equals("Shanghai", "address", "city")).Find(&users)
+type JSONQueryExpression struct {
+	column   string
+	keys     []string
+	hasKey   bool
+	equals   bool
+	comparer interface{}
+}
+
+// JSONQuery query conditions of a json column
+func JSONQuery(column string) *JSONQueryExpression {
+	return &JSONQueryExpression{column: column}
+}
+
+// HasKey the json value contains the key path
+func (expr *JSONQueryExpression) HasKey(keys ...string) *JSONQueryExpression {
+	expr.keys = keys
+	expr.hasKey = true
+	return expr
+}
+
+// Equals the json value of the key path equals to value
+func (expr *JSONQueryExpression) Equals(value interface{}, keys ...string) *JSONQueryExpression {
+	expr.keys = keys
+	expr.equals = true
+	expr.comparer = value
+	return expr
+}
+
+// Build build json query conditions for current dialect
+func (expr *JSONQueryExpression) Build(builder clause.Builder) string {
+	var dialect string
+	if b, ok := builder.(clauseBuilder); ok {
+		dialect = b.scope.Dialect().GetName()
+	}
+
+	column := builder.Column(expr.column)
+	if len(expr.keys) == 0 || (!expr.hasKey && !expr.equals) {
+		return ""
+	}
+
+	switch dialect {
+	case "postgres":
+		if expr.hasKey {
+			var path []string
+			for _, key := range expr.keys {
+				path = append(path, builder.AddVar(key))
+			}
+			return fmt.Sprintf("%v->%v IS NOT NULL", column, strings.Join(path, "->"))
+		}
+
+		value := expr.comparer
+		for idx := len(expr.keys) - 1; idx >= 0; idx-- {
+			value = map[string]interface{}{expr.keys[idx]: value}
+		}
+		bytes, _ := json.Marshal(value)
+		return fmt.Sprintf("%v @> %v", column, builder.AddVar(string(bytes)))
+	case "mssql":
+		path := builder.AddVar(jsonPath(expr.keys))
+		if expr.hasKey {
+			return fmt.Sprintf("(JSON_VALUE(%v, %v) IS NOT NULL OR JSON_QUERY(%v, %v) IS NOT NULL)", column, path, column, path)
+		}
+		return fmt.Sprintf("JSON_VALUE(%v, %v) = %v", column, path, builder.AddVar(expr.comparer))
+	default:
+		path := builder.AddVar(jsonPath(expr.keys))
+		if expr.hasKey {
+			return fmt.Sprintf("JSON_EXTRACT(%v, %v) IS NOT NULL", column, path)
+		}
+		return fmt.Sprintf("JSON_EXTRACT(%v, %v) = %v", column, path, builder.AddVar(expr.comparer))
+	}
+}
+
+// jsonPath build keys into json path like `$."address"."city"`
+func jsonPath(keys []string) string {
+	path := "$"
+	for _, key := range keys {
+		path += fmt.Sprintf(".%q", key)
+	}
+	return path
+}
@@ -0,0 +1,73 @@
+package gorm_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+)
+
+type JSONUser struct {
+	ID    uint
+	Name  string
+	Attrs gorm.JSON
+}
+
+func TestJSON(t *testing.T) {
+	DB.DropTableIfExists(&JSONUser{})
+	if err := DB.AutoMigrate(&JSONUser{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate, got %v", err)
+	}
+
+	users := []JSONUser{
+		{Name: "json1", Attrs: gorm.JSON(`{"age":18,"address":{"city":"Shanghai"}}`)},
+		{Name: "json2", Attrs: gorm.JSON(`{"age":20,"address":{"city":"Beijing"},"orgs":["a"]}`)},
+		{Name: "json3"},
+	}
+	for i := range users {
+		if err := DB.Save(&users[i]).Error; err != nil {
+			t.Fatalf("Should save json field, but got %v", err)
+		}
+	}
+
+	var result JSONUser
+	if err := DB.First(&result, users[0].ID).Error; err != nil {
+		t.Fatalf("Should find record, but got %v", err)
+	}
+
+	var attrs map[string]interface{}
+	if err := json.Unmarshal(result.Attrs, &attrs); err != nil || attrs["age"] != float64(18) {
+		t.Errorf("Should load json field, but got %v, %v", string(result.Attrs), err)
+	}
+
+	var blank JSONUser
+	if DB.First(&blank, users[2].ID); blank.Attrs != nil {
+		t.Errorf("Blank json field should be nil, but got %v", string(blank.Attrs))
+	}
+
+	if data, err := json.Marshal(result); err != nil || !json.Valid(data) {
+		t.Errorf("Should marshal json field as raw json, but got %v, %v", string(data), err)
+	}
+
+	if dialect := DB.Dialect().GetName(); dialect == "sqlite3" && DB.Exec("SELECT JSON_EXTRACT('{}', '$')").Error != nil {
+		t.Skip("Skipping this because sqlite is built without JSON1 extension")
+	}
+
+	var found []JSONUser
+	if DB.Where(gorm.JSONQuery("attrs").HasKey("orgs")).Find(&found); len(found) != 1 || found[0].Name != "json2" {
+		t.Errorf("Should find records with json key, but got %v", found)
+	}
+
+	if DB.Where(gorm.JSONQuery("Attrs").HasKey("address", "city")).Find(&found); len(found) != 2 {
+		t.Errorf("Should find records with nested json key, but got %v", found)
+	}
+
+	if DB.Where(gorm.JSONQuery("attrs").Equals("Beijing", "address", "city")).Find(&found); len(found) != 1 || found[0].Name != "json2" {
+		t.Errorf("Should find records with json value, but got %v", found)
+	}
+
+	if DB.Where("name <> ? AND ?", "json1", gorm.JSONQuery("attrs").Equals(18, "age")).Find(&found); len(found) != 0 {
+		t.Errorf("Should use json conditions in string conditions, but got %v", found)
+	}
+
+}
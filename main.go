@@ -26,11 +26,20 @@ type DB struct {
 	search            *search
 	values            sync.Map
 
+	// tables changed in current transaction, their cached results will be invalidated again after committed
+	txInvalidatedTables *sync.Map
+
 	// global db
 	parent        *DB
 	callbacks     *Callback
 	dialect       Dialect
 	singularTable bool
+	queryCache    QueryCache
+
+	// tables whose cached results also depend on the table, e.g. joined tables
+	queryCacheDependents map[string]map[string]bool
+
+	encryptionKeyProvider EncryptionKeyProvider
 
 	// function to be used to override the creating of a new timestamp
 	nowFuncOverride func() time.Time
@@ -199,6 +208,24 @@ func (s *DB) SingularTable(enable bool) {
 	s.parent.singularTable = enable
 }
 
+// SetQueryCache set the cache for query results, queries will only be cached when enabled with `db.Set("gorm:cache", ttl)`
+//     db.SetQueryCache(gorm.NewLRUQueryCache(1000))
+//     db.Set("gorm:cache", time.Minute).Find(&languages)
+func (s *DB) SetQueryCache(cache QueryCache) {
+	s.parent.Lock()
+	defer s.parent.Unlock()
+	s.parent.queryCache = cache
+	s.parent.queryCacheDependents = nil
+}
+
+// SetEncryptionKeyProvider set the key provider to encrypt and decrypt fields tagged with `encrypted`
+//     db.SetEncryptionKeyProvider(gorm.NewStaticKeyProvider("v1", map[string][]byte{"v1": key}, hashKey))
+func (s *DB) SetEncryptionKeyProvider(provider EncryptionKeyProvider) {
+	s.parent.Lock()
+	defer s.parent.Unlock()
+	s.parent.encryptionKeyProvider = provider
+}
+
 // NewScope create a scope for current operation
 func (s *DB) NewScope(value interface{}) *Scope {
 	dbClone := s.clone()
@@ -212,6 +239,14 @@ func (s *DB) NewScope(value interface{}) *Scope {
 	return scope
 }
 
+// newQueryScope create a scope to query records into out, map destinations are queried with the model or table of current db
+func (s *DB) newQueryScope(out interface{}) *Scope {
+	if isMapDestination(out) {
+		return s.NewScope(s.Value).Set("gorm:query_destination", out)
+	}
+	return s.NewScope(out)
+}
+
 // QueryExpr returns the query as SqlExpr object
 func (s *DB) QueryExpr() *SqlExpr {
 	scope := s.NewScope(s.Value)
@@ -230,7 +265,19 @@ func (s *DB) SubQuery() *SqlExpr {
 	return Expr(fmt.Sprintf("(%v)", scope.SQL), scope.SQLVars...)
 }
 
-// Where return a new relation, filter records with given conditions, accepts `map`, `struct` or `string` as conditions, refer http://jinzhu.github.io/gorm/crud.html#query
+// ExistsExpr returns the query as `EXISTS (sub query)` condition, could be used to filter records with correlated sub query
+//     db.Where(db.Model(&Order{}).Where("orders.user_id = users.id").ExistsExpr()).Find(&users)
+//     db.Not(db.Model(&Order{}).Where("orders.user_id = users.id").ExistsExpr()).Find(&users)
+func (s *DB) ExistsExpr() *SqlExpr {
+	scope := s.NewScope(s.Value)
+	scope.InstanceSet("skip_bindvar", true)
+	scope.prepareExistsQuery()
+	scope.prepareQuerySQL()
+
+	return Expr(fmt.Sprintf("EXISTS (%v)", scope.SQL), scope.SQLVars...)
+}
+
+// Where return a new relation, filter records with given conditions, accepts `map`, `struct`, `string` or `clause.Expression` as conditions, refer http://jinzhu.github.io/gorm/crud.html#query
 func (s *DB) Where(query interface{}, args ...interface{}) *DB {
 	return s.clone().search.Where(query, args...).db
 }
@@ -259,6 +306,7 @@ func (s *DB) Offset(offset interface{}) *DB {
 //     db.Order("name DESC")
 //     db.Order("name DESC", true) // reorder
 //     db.Order(gorm.Expr("name = ? DESC", "first")) // sql expression
+//     db.Order(clause.Desc("Name")) // clause expression
 func (s *DB) Order(value interface{}, reorder ...bool) *DB {
 	return s.clone().search.Order(value, reorder...).db
 }
@@ -269,6 +317,13 @@ func (s *DB) Select(query interface{}, args ...interface{}) *DB {
 	return s.clone().search.Select(query, args...).db
 }
 
+// Distinct specify to retrieve distinct records, columns are used as selected fields if given, works with `Find`, `Count` and `Pluck`
+//     db.Distinct("name", "age").Find(&users)
+//     db.Model(&User{}).Distinct("name").Count(&count)
+func (s *DB) Distinct(columns ...string) *DB {
+	return s.clone().search.Distinct(columns...).db
+}
+
 // Omit specify fields that you want to ignore when saving to database for creating, updating
 func (s *DB) Omit(columns ...string) *DB {
 	return s.clone().search.Omit(columns...).db
@@ -290,6 +345,34 @@ func (s *DB) Joins(query string, args ...interface{}) *DB {
 	return s.clone().search.Joins(query, args...).db
 }
 
+// With define a named common table expression, which will be rendered before the SELECT statement
+//     db.With("recent_orders", db.Model(&Order{}).Where("created_at > ?", t).QueryExpr()).Table("recent_orders").Find(&orders)
+//     db.With("recent_orders", db.Model(&Order{}).Where("created_at > ?", t), true) // postgres: AS MATERIALIZED
+func (s *DB) With(name string, query interface{}, materialized ...bool) *DB {
+	return s.clone().search.With(name, query, materialized...).db
+}
+
+// Union combine the results of queries and remove duplicated rows, the combined results could be ordered, limited and scanned like a table
+//     db.Union(db.Table("orders"), db.Table("archived_orders")).Order("id").Limit(10).Find(&orders)
+func (s *DB) Union(queries ...*DB) *DB {
+	return s.compound("UNION", queries)
+}
+
+// UnionAll combine the results of queries without removing duplicated rows, refer `Union` for usage
+func (s *DB) UnionAll(queries ...*DB) *DB {
+	return s.compound("UNION ALL", queries)
+}
+
+// Intersect return rows that exist in the results of all queries, refer `Union` for usage
+func (s *DB) Intersect(queries ...*DB) *DB {
+	return s.compound("INTERSECT", queries)
+}
+
+// Except return rows of the first query that don't exist in the results of the other queries, refer `Union` for usage
+func (s *DB) Except(queries ...*DB) *DB {
+	return s.compound("EXCEPT", queries)
+}
+
 // Scopes pass current database connection to arguments `func(*DB) *DB`, which could be used to add conditions dynamically
 //     func AmountGreaterThan1000(db *gorm.DB) *gorm.DB {
 //         return db.Where("amount > ?", 1000)
@@ -327,7 +410,7 @@ func (s *DB) Assign(attrs ...interface{}) *DB {
 
 // First find first record that match given conditions, order by primary key
 func (s *DB) First(out interface{}, where ...interface{}) *DB {
-	newScope := s.NewScope(out)
+	newScope := s.newQueryScope(out)
 	newScope.Search.Limit(1)
 
 	return newScope.Set("gorm:order_by_primary_key", "ASC").
@@ -336,22 +419,24 @@ func (s *DB) First(out interface{}, where ...interface{}) *DB {
 
 // Take return a record that match given conditions, the order will depend on the database implementation
 func (s *DB) Take(out interface{}, where ...interface{}) *DB {
-	newScope := s.NewScope(out)
+	newScope := s.newQueryScope(out)
 	newScope.Search.Limit(1)
 	return newScope.inlineCondition(where...).callCallbacks(s.parent.callbacks.queries).db
 }
 
 // Last find last record that match given conditions, order by primary key
 func (s *DB) Last(out interface{}, where ...interface{}) *DB {
-	newScope := s.NewScope(out)
+	newScope := s.newQueryScope(out)
 	newScope.Search.Limit(1)
 	return newScope.Set("gorm:order_by_primary_key", "DESC").
 		inlineCondition(where...).callCallbacks(s.parent.callbacks.queries).db
 }
 
-// Find find records that match given conditions
+// Find find records that match given conditions, records could also be found into maps keyed by column names with `Model` or `Table`
+//     var results []map[string]interface{}
+//     db.Table("users").Where("age > ?", 18).Find(&results)
 func (s *DB) Find(out interface{}, where ...interface{}) *DB {
-	return s.NewScope(out).inlineCondition(where...).callCallbacks(s.parent.callbacks.queries).db
+	return s.newQueryScope(out).inlineCondition(where...).callCallbacks(s.parent.callbacks.queries).db
 }
 
 //Preloads preloads relations, don`t touch out
@@ -374,7 +459,7 @@ func (s *DB) Rows() (*sql.Rows, error) {
 	return s.NewScope(s.Value).rows()
 }
 
-// ScanRows scan `*sql.Rows` to give struct
+// ScanRows scan `*sql.Rows` to give struct or map
 func (s *DB) ScanRows(rows *sql.Rows, result interface{}) error {
 	var (
 		scope        = s.NewScope(result)
@@ -383,7 +468,14 @@ func (s *DB) ScanRows(rows *sql.Rows, result interface{}) error {
 	)
 
 	if clone.AddError(err) == nil {
-		scope.scan(rows, columns, scope.Fields())
+		if dest := indirect(reflect.ValueOf(result)); dest.Kind() == reflect.Map {
+			if dest.IsNil() {
+				dest.Set(reflect.MakeMap(dest.Type()))
+			}
+			scope.scanMap(rows, columns, dest)
+		} else {
+			scope.scan(rows, columns, scope.Fields())
+		}
 	}
 
 	return clone.Error
@@ -396,11 +488,63 @@ func (s *DB) Pluck(column string, value interface{}) *DB {
 	return s.NewScope(s.Value).pluck(column, value).db
 }
 
+// PluckMap used to query two columns from a model as a map, error will be returned when the key column has duplicate values
+//     var names map[uint]string
+//     db.Model(&User{}).PluckMap("id", "name", &names)
+func (s *DB) PluckMap(keyColumn, valueColumn string, value interface{}) *DB {
+	return s.NewScope(s.Value).pluckMap(keyColumn, valueColumn, value).db
+}
+
+// PluckColumns used to query columns from a model into a slice of struct, columns are matched to fields by column names
+//     var results []struct{ ID uint; Name string }
+//     db.Model(&User{}).PluckColumns(&results, "id", "name")
+func (s *DB) PluckColumns(value interface{}, columns ...string) *DB {
+	return s.NewScope(s.Value).pluckColumns(value, columns...).db
+}
+
 // Count get how many records for a model
 func (s *DB) Count(value interface{}) *DB {
 	return s.NewScope(s.Value).count(value).db
 }
 
+// Exists check if any record matches current conditions, the query stops at the first matched record instead of counting all of them
+//     exists, err := db.Model(&User{}).Where("name = ?", "jinzhu").Exists()
+func (s *DB) Exists() (bool, error) {
+	var exists bool
+	scope := s.NewScope(s.Value).exists(&exists)
+	return exists, scope.db.Error
+}
+
+// Sum get sum of the column for a model, NULL result will be set to zero value.
+// When grouped, value should be a map keyed by the group column, or a slice of struct having the group columns and a `Sum` field
+//     var total int64
+//     db.Model(&Order{}).Where("state = ?", "paid").Sum("amount", &total)
+//     var totals map[uint]int64
+//     db.Model(&Order{}).Group("user_id").Sum("amount", &totals)
+func (s *DB) Sum(column string, value interface{}) *DB {
+	return s.NewScope(s.Value).aggregate("SUM", column, value).db
+}
+
+// Avg get average of the column for a model, refer `Sum` for usage, the field of grouped struct results is `Avg`
+func (s *DB) Avg(column string, value interface{}) *DB {
+	return s.NewScope(s.Value).aggregate("AVG", column, value).db
+}
+
+// Min get minimum of the column for a model, refer `Sum` for usage, the field of grouped struct results is `Min`
+func (s *DB) Min(column string, value interface{}) *DB {
+	return s.NewScope(s.Value).aggregate("MIN", column, value).db
+}
+
+// Max get maximum of the column for a model, refer `Sum` for usage, the field of grouped struct results is `Max`
+func (s *DB) Max(column string, value interface{}) *DB {
+	return s.NewScope(s.Value).aggregate("MAX", column, value).db
+}
+
+// CountDistinct get how many distinct values of the column for a model, refer `Sum` for usage, the field of grouped struct results is `Count`
+func (s *DB) CountDistinct(column string, value interface{}) *DB {
+	return s.NewScope(s.Value).aggregate("COUNT DISTINCT", column, value).db
+}
+
 // Related get related associations
 func (s *DB) Related(value interface{}, foreignKeys ...string) *DB {
 	return s.NewScope(s.Value).related(value, foreignKeys...).db
@@ -481,10 +625,41 @@ func (s *DB) UpdateColumns(values interface{}) *DB {
 		callCallbacks(s.parent.callbacks.updates).db
 }
 
-// Save update value in database, if the value doesn't have primary key, will insert it
+// TrackChanges returns a session tracking changes of records found with it, snapshots of the records are kept in the session
+// until the records saved or deleted
+//     tx := db.TrackChanges()
+//     tx.First(&user)
+//     user.Email = "jinzhu@example.org"
+//     tx.Changed(&user, "Email") // true
+//     tx.Save(&user)             // only update changed fields
+func (s *DB) TrackChanges() *DB {
+	return s.Set("gorm:track_changes", &changeTracker{})
+}
+
+// ResetChanges drops snapshots of all records tracked by the session returned by `TrackChanges`
+func (s *DB) ResetChanges() *DB {
+	if tracker, ok := s.NewScope(nil).changeTracker(); ok {
+		tracker.reset()
+	}
+	return s
+}
+
+// Changed check if any of the fields changed since the record was found with the session returned by `TrackChanges`, check all fields if no field given
+func (s *DB) Changed(value interface{}, fields ...string) bool {
+	return s.NewScope(value).Changed(fields...)
+}
+
+// Changes returns changed fields of the record since it was found with the session returned by `TrackChanges`, keyed by field names
+func (s *DB) Changes(value interface{}) map[string]FieldChange {
+	return s.NewScope(value).Changes()
+}
+
+// Save update value in database, if the value doesn't have primary key, will insert it;
+// only changed fields will be updated if the record was found with the same session returned by `TrackChanges`
 func (s *DB) Save(value interface{}) *DB {
 	scope := s.NewScope(value)
 	if !scope.PrimaryKeyZero() {
+		scope.selectChangedFields()
 		newDB := scope.callCallbacks(s.parent.callbacks.updates).db
 		if newDB.Error == nil && newDB.RowsAffected == 0 {
 			return s.New().Table(scope.TableName()).FirstOrCreate(value)
@@ -494,9 +669,14 @@ func (s *DB) Save(value interface{}) *DB {
 	return scope.callCallbacks(s.parent.callbacks.creates).db
 }
 
-// Create insert the value into database
+// Create insert the value into database, a map keyed by column names could be inserted with `Model` or `Table`
+//     db.Table("users").Create(map[string]interface{}{"name": "jinzhu", "age": 18})
 func (s *DB) Create(value interface{}) *DB {
 	scope := s.NewScope(value)
+	if s.Value != nil && indirect(reflect.ValueOf(value)).Kind() == reflect.Map {
+		// insert the map into table of the model
+		scope.Search.Table(s.NewScope(s.Value).TableName())
+	}
 	return scope.callCallbacks(s.parent.callbacks.creates).db
 }
 
@@ -570,6 +750,21 @@ func (s *DB) Table(name string) *DB {
 	return clone
 }
 
+// AsOf query rows of historical models as they were at the time, see `Historical`
+//     db.AsOf(time.Now().AddDate(0, 0, -7)).Where("name = ?", "jinzhu").Find(&users)
+func (s *DB) AsOf(t time.Time) *DB {
+	return s.Set("gorm:as_of", t)
+}
+
+// From specify a sub query as the table you would like to query from, the alias is used as its table name
+//     db.From(db.Table("orders").Select("user_id, sum(amount) AS total").Group("user_id"), "totals").Where("total > ?", 100).Find(&totals)
+func (s *DB) From(query interface{}, alias string) *DB {
+	clone := s.clone()
+	clone.search.From(query, alias)
+	clone.Value = nil
+	return clone
+}
+
 // Debug start debug mode
 func (s *DB) Debug() *DB {
 	return s.clone().LogMode(true)
@@ -597,9 +792,9 @@ func (s *DB) Transaction(fc func(tx *DB) error) (err error) {
 	return
 }
 
-// Begin begins a transaction
+// Begin begins a transaction with the context set with `WithContext`
 func (s *DB) Begin() *DB {
-	return s.BeginTx(context.Background(), &sql.TxOptions{})
+	return s.BeginTx(s.NewScope(nil).Context(), &sql.TxOptions{})
 }
 
 // BeginTx begins a transaction with options
@@ -608,6 +803,7 @@ func (s *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) *DB {
 	if db, ok := c.db.(sqlDb); ok && db != nil {
 		tx, err := db.BeginTx(ctx, opts)
 		c.db = interface{}(tx).(SQLCommon)
+		c.txInvalidatedTables = &sync.Map{}
 
 		c.dialect.SetDB(c.db)
 		c.AddError(err)
@@ -621,7 +817,9 @@ func (s *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) *DB {
 func (s *DB) Commit() *DB {
 	var emptySQLTx *sql.Tx
 	if db, ok := s.db.(sqlTx); ok && db != nil && db != emptySQLTx {
-		s.AddError(db.Commit())
+		if s.AddError(db.Commit()) == nil {
+			invalidateCommittedQueryCache(s)
+		}
 	} else {
 		s.AddError(ErrInvalidTransaction)
 	}
@@ -724,7 +922,9 @@ func (s *DB) HasTable(value interface{}) bool {
 	return has
 }
 
-// AutoMigrate run auto migration for given models, will only add missing fields, won't delete/change current data
+// AutoMigrate run auto migration for given models, will add missing fields and alter types, nullability and defaults of changed columns,
+// won't delete current columns, set `gorm:safe_migration` to only widen columns, e.g:
+//     db.Set("gorm:safe_migration", true).AutoMigrate(&User{})
 func (s *DB) AutoMigrate(values ...interface{}) *DB {
 	db := s.Unscoped()
 	for _, value := range values {
@@ -784,6 +984,14 @@ func (s *DB) RemoveForeignKey(field string, dest string) *DB {
 	return scope.db
 }
 
+// DropCheck drop CHECK constraint with name from the given scope, sqlite tables will be rebuilt without the constraint, e.g:
+//     db.Model(&Product{}).DropCheck("price_nonneg")
+func (s *DB) DropCheck(name string) *DB {
+	scope := s.NewScope(s.Value)
+	scope.dropCheck(name)
+	return scope.db
+}
+
 // Association start `Association Mode` to handler relations things easir in that mode, refer: https://jinzhu.github.io/gorm/associations.html#association-mode
 func (s *DB) Association(column string) *Association {
 	var err error
@@ -812,6 +1020,13 @@ func (s *DB) Preload(column string, conditions ...interface{}) *DB {
 	return s.clone().search.Preload(column, conditions...).db
 }
 
+// WithContext set the context of current operations, sql are executed with the context, so they are cancelled when the context done,
+// it could also be used by callbacks with `scope.Context()`
+//     db.WithContext(ctx).Save(&user)
+func (s *DB) WithContext(ctx context.Context) *DB {
+	return s.Set("gorm:context", ctx)
+}
+
 // Set set setting by name, which could be used in callbacks, will clone a new db, and update its setting
 func (s *DB) Set(name string, value interface{}) *DB {
 	return s.clone().InstantSet(name, value)
@@ -894,6 +1109,8 @@ func (s *DB) clone() *DB {
 		blockGlobalUpdate: s.blockGlobalUpdate,
 		dialect:           newDialect(s.dialect.GetName(), s.db),
 		nowFuncOverride:   s.nowFuncOverride,
+
+		txInvalidatedTables: s.txInvalidatedTables,
 	}
 
 	s.values.Range(func(k, v interface{}) bool {
@@ -911,6 +1128,14 @@ func (s *DB) clone() *DB {
 	return db
 }
 
+func (s *DB) compound(operator string, queries []*DB) *DB {
+	var values []interface{}
+	for _, query := range queries {
+		values = append(values, query)
+	}
+	return s.clone().search.Compound(operator, values...).db
+}
+
 func (s *DB) print(v ...interface{}) {
 	s.logger.Print(v...)
 }
@@ -0,0 +1,89 @@
+package gorm
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QueryCache is the interface to cache query results, results are keyed by table name, sql and vars,
+// and will be invalidated by table name after creating, updating or deleting records of the table
+//     db.SetQueryCache(gorm.NewLRUQueryCache(1000))
+//     db.Set("gorm:cache", time.Minute).Find(&languages)
+type QueryCache interface {
+	Get(table, sql string, vars []interface{}) (value interface{}, ok bool)
+	Set(table, sql string, vars []interface{}, value interface{}, ttl time.Duration)
+	Invalidate(table string)
+}
+
+// NewLRUQueryCache returns an in-memory QueryCache, which keeps at most size results, least recently used results will be evicted first
+func NewLRUQueryCache(size int) QueryCache {
+	return &lruQueryCache{size: size, entries: map[string]*list.Element{}, list: list.New()}
+}
+
+type lruQueryCache struct {
+	mutex   sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	list    *list.List
+}
+
+type lruQueryCacheEntry struct {
+	key       string
+	table     string
+	value     interface{}
+	expiresAt time.Time
+}
+
+func (cache *lruQueryCache) key(table, sql string, vars []interface{}) string {
+	return fmt.Sprintf("%v\x00%v\x00%#v", table, sql, vars)
+}
+
+func (cache *lruQueryCache) Get(table, sql string, vars []interface{}) (interface{}, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if elem, ok := cache.entries[cache.key(table, sql, vars)]; ok {
+		entry := elem.Value.(*lruQueryCacheEntry)
+		if time.Now().Before(entry.expiresAt) {
+			cache.list.MoveToFront(elem)
+			return entry.value, true
+		}
+		cache.remove(elem)
+	}
+	return nil, false
+}
+
+func (cache *lruQueryCache) Set(table, sql string, vars []interface{}, value interface{}, ttl time.Duration) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	key := cache.key(table, sql, vars)
+	if elem, ok := cache.entries[key]; ok {
+		cache.remove(elem)
+	}
+
+	cache.entries[key] = cache.list.PushFront(&lruQueryCacheEntry{key: key, table: table, value: value, expiresAt: time.Now().Add(ttl)})
+	for cache.size > 0 && cache.list.Len() > cache.size {
+		cache.remove(cache.list.Back())
+	}
+}
+
+func (cache *lruQueryCache) Invalidate(table string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	for elem := cache.list.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*lruQueryCacheEntry).table == table {
+			cache.remove(elem)
+		}
+		elem = next
+	}
+}
+
+func (cache *lruQueryCache) remove(elem *list.Element) {
+	cache.list.Remove(elem)
+	delete(cache.entries, elem.Value.(*lruQueryCacheEntry).key)
+}
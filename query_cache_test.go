@@ -0,0 +1,157 @@
+package gorm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+func TestQueryCache(t *testing.T) {
+	DB.SetQueryCache(gorm.NewLRUQueryCache(100))
+	defer DB.SetQueryCache(nil)
+
+	DB.Save(&User{Name: "QueryCacheUser", Age: 10})
+	cachedDB := DB.Set("gorm:cache", time.Minute).Where("name = ?", "QueryCacheUser")
+
+	var users []User
+	if cachedDB.Find(&users); len(users) != 1 || users[0].Age != 10 {
+		t.Fatalf("Should find users, but got %v", len(users))
+	}
+
+	// raw sql won't invalidate the cache
+	DB.Exec("UPDATE users SET age = ? WHERE name = ?", 20, "QueryCacheUser")
+
+	var cachedUsers []User
+	if cachedDB.Find(&cachedUsers); len(cachedUsers) != 1 || cachedUsers[0].Age != 10 {
+		t.Errorf("Should find users from cache, but got %v", cachedUsers)
+	}
+
+	var user User
+	if cachedDB.First(&user); user.Age != 20 {
+		t.Errorf("Query with different sql should not be cached, but got %v", user.Age)
+	}
+
+	if DB.Where("name = ?", "QueryCacheUser").Find(&users); users[0].Age != 20 {
+		t.Errorf("Query without cache enabled should not be cached, but got %v", users[0].Age)
+	}
+
+	DB.Model(&User{}).Where("name = ?", "QueryCacheUser").Update("age", 30)
+	if cachedDB.Find(&cachedUsers); len(cachedUsers) != 1 || cachedUsers[0].Age != 30 {
+		t.Errorf("Cache should be invalidated after updating, but got %v", cachedUsers)
+	}
+
+	tx := DB.Begin()
+	tx.Model(&User{}).Where("name = ?", "QueryCacheUser").Update("age", 40)
+	if cachedDB.Find(&cachedUsers); len(cachedUsers) != 1 || cachedUsers[0].Age != 30 {
+		t.Errorf("Should find committed records, but got %v", cachedUsers)
+	}
+	tx.Commit()
+
+	if cachedDB.Find(&cachedUsers); len(cachedUsers) != 1 || cachedUsers[0].Age != 40 {
+		t.Errorf("Cache should be invalidated after transaction committed, but got %v", cachedUsers)
+	}
+
+	DB.Save(&User{Name: "QueryCacheUser", Age: 50})
+	if cachedDB.Find(&cachedUsers); len(cachedUsers) != 2 {
+		t.Errorf("Cache should be invalidated after creating, but got %v", len(cachedUsers))
+	}
+
+	DB.Where("name = ? AND age = ?", "QueryCacheUser", 50).Delete(&User{})
+	if cachedDB.Find(&cachedUsers); len(cachedUsers) != 1 {
+		t.Errorf("Cache should be invalidated after deleting, but got %v", len(cachedUsers))
+	}
+}
+
+func TestQueryCacheWithAssociations(t *testing.T) {
+	DB.SetQueryCache(gorm.NewLRUQueryCache(100))
+	defer DB.SetQueryCache(nil)
+
+	user := User{Name: "QueryCacheAssociationsUser", Age: 10, Emails: []Email{{Email: "query_cache1@example.org"}}}
+	DB.Save(&user)
+	cachedDB := DB.Set("gorm:cache", time.Minute).Where("name = ?", user.Name)
+
+	var pointers []*User
+	cachedDB.Find(&pointers)
+	if len(pointers) != 1 {
+		t.Fatalf("Should find users, but got %v", len(pointers))
+	}
+	pointers[0].Age = 20
+
+	if cachedDB.Find(&pointers); len(pointers) != 1 || pointers[0].Age != 10 {
+		t.Errorf("Changing found records should not change cached results, but got %v", pointers[0].Age)
+	}
+
+	var users []User
+	if cachedDB.Preload("Emails").Find(&users); len(users) != 1 || len(users[0].Emails) != 1 {
+		t.Fatalf("Should preload emails, but got %v", users)
+	}
+
+	DB.Save(&Email{UserId: int(user.Id), Email: "query_cache2@example.org"})
+	if cachedDB.Preload("Emails").Find(&users); len(users) != 1 || len(users[0].Emails) != 2 {
+		t.Errorf("Preloaded associations should be refreshed after changed, but got %v", users[0].Emails)
+	}
+
+	joinedDB := cachedDB.Joins("JOIN emails ON emails.user_id = users.id")
+	joinedUsers := []User{}
+	if joinedDB.Find(&joinedUsers); len(joinedUsers) != 2 {
+		t.Errorf("Should find joined records, but got %v", len(joinedUsers))
+	}
+
+	DB.Where("email = ?", "query_cache2@example.org").Delete(&Email{})
+	if joinedDB.Find(&joinedUsers); len(joinedUsers) != 1 {
+		t.Errorf("Cached results should be invalidated after joined table changed, but got %v", len(joinedUsers))
+	}
+}
+
+func TestQueryCacheWithRolledBackTransaction(t *testing.T) {
+	DB.SetQueryCache(gorm.NewLRUQueryCache(100))
+	defer DB.SetQueryCache(nil)
+
+	DB.Save(&User{Name: "QueryCacheRollbackUser", Age: 10})
+	cachedDB := DB.Set("gorm:cache", time.Minute).Where("name = ?", "QueryCacheRollbackUser")
+
+	tx := DB.Begin()
+	tx.Model(&User{}).Where("name = ?", "QueryCacheRollbackUser").Update("age", 20)
+
+	var user User
+	if tx.Set("gorm:cache", time.Minute).Where("name = ?", "QueryCacheRollbackUser").First(&user); user.Age != 20 {
+		t.Errorf("Should find uncommitted records in transaction, but got %v", user.Age)
+	}
+	tx.Rollback()
+
+	var cachedUser User
+	if cachedDB.First(&cachedUser); cachedUser.Age != 10 {
+		t.Errorf("Uncommitted records should not be cached after rollback, but got %v", cachedUser.Age)
+	}
+}
+
+func TestLRUQueryCache(t *testing.T) {
+	cache := gorm.NewLRUQueryCache(2)
+	cache.Set("users", "SELECT 1", []interface{}{1}, 1, time.Minute)
+	cache.Set("users", "SELECT 1", []interface{}{"1"}, 2, time.Minute)
+
+	if value, ok := cache.Get("users", "SELECT 1", []interface{}{1}); !ok || value != 1 {
+		t.Errorf("Should get cached value, but got %v", value)
+	}
+
+	cache.Set("emails", "SELECT 1", nil, 3, time.Minute)
+	if _, ok := cache.Get("users", "SELECT 1", []interface{}{"1"}); ok {
+		t.Errorf("Least recently used value should be evicted")
+	}
+
+	cache.Invalidate("users")
+	if _, ok := cache.Get("users", "SELECT 1", []interface{}{1}); ok {
+		t.Errorf("Value should be invalidated by table name")
+	}
+
+	if value, ok := cache.Get("emails", "SELECT 1", nil); !ok || value != 3 {
+		t.Errorf("Value of other tables should not be invalidated, but got %v", value)
+	}
+
+	cache.Set("emails", "SELECT 2", nil, 4, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	if _, ok := cache.Get("emails", "SELECT 2", nil); ok {
+		t.Errorf("Expired value should not be returned")
+	}
+}
@@ -1,8 +1,11 @@
 package gorm_test
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 
 	"github.com/jinzhu/gorm"
 
@@ -345,6 +348,58 @@ func TestSearchWithMap(t *testing.T) {
 	}
 }
 
+func TestSearchWithNamedParameters(t *testing.T) {
+	user1 := User{Name: "NamedSearchUser1", Age: 1, Email: "named1@example.org"}
+	user2 := User{Name: "NamedSearchUser2", Age: 10, Email: "named2@example.org"}
+	user3 := User{Name: "NamedSearchUser3", Age: 20, Email: "named3@example.org"}
+	DB.Save(&user1).Save(&user2).Save(&user3)
+
+	var users []User
+	DB.Where("name LIKE @name AND (age = @age OR age > @age + 15)", sql.Named("name", "NamedSearchUser%"), sql.Named("age", 1)).Order("age").Find(&users)
+	if len(users) != 2 || users[0].Name != user1.Name || users[1].Name != user3.Name {
+		t.Errorf("Search with sql.Named parameters, but got %v", len(users))
+	}
+
+	DB.Where("name IN (@names) AND email <> 'a@b.org'", map[string]interface{}{"names": []string{user1.Name, user2.Name}}).Find(&users)
+	if len(users) != 2 {
+		t.Errorf("Search with map parameters, but got %v", len(users))
+	}
+
+	DB.Raw("SELECT * FROM users WHERE name = @Name AND age = @age", User{Name: user2.Name, Age: 10}).Scan(&users)
+	if len(users) != 1 || users[0].Name != user2.Name {
+		t.Errorf("Search with struct parameters, but got %v", len(users))
+	}
+
+	DB.Exec("UPDATE users SET email = @email WHERE name = @name OR email = @email", map[string]interface{}{"name": user3.Name, "email": "named_new@example.org"})
+	var user User
+	if DB.Where("email = @email", sql.Named("email", "named_new@example.org")).First(&user); user.Name != user3.Name {
+		t.Errorf("Exec with named parameters, but got %v", user.Name)
+	}
+
+	if err := DB.Where("name = @unknown", sql.Named("name", "x")).Find(&users).Error; err == nil {
+		t.Errorf("Should get error with unknown named parameter")
+	}
+}
+
+func TestSearchWithAtSignAndPositionalStruct(t *testing.T) {
+	var vars []interface{}
+	DB.Callback().Query().After("gorm:query").Register("TestSearchWithAtSignAndPositionalStruct", func(scope *gorm.Scope) {
+		vars = scope.SQLVars
+	})
+	defer DB.Callback().Query().Remove("TestSearchWithAtSignAndPositionalStruct")
+
+	arg := struct{ Name string }{Name: "at_sign"}
+	DB.Where("name = ? AND email NOT LIKE '%@%'", arg).Find(&[]User{})
+	if len(vars) != 1 || !reflect.DeepEqual(vars[0], arg) {
+		t.Errorf("Struct argument should be used as positional argument if no named parameter bound, but got %v", vars)
+	}
+
+	DB.Where("name = ? AND age @> 1", map[string]interface{}{"name": "at_sign"}).Find(&[]User{})
+	if len(vars) != 1 {
+		t.Errorf("Map argument should be used as positional argument if no named parameter bound, but got %v", vars)
+	}
+}
+
 func TestSearchWithEmptyChain(t *testing.T) {
 	user1 := User{Name: "ChainSearchUser1", Age: 1, Birthday: parseTime("2000-1-1")}
 	user2 := User{Name: "ChainearchUser2", Age: 10, Birthday: parseTime("2010-1-1")}
@@ -570,6 +625,331 @@ func TestCount(t *testing.T) {
 	}
 }
 
+func TestCountWithComplexQuery(t *testing.T) {
+	DB.Save(&User{Name: "ComplexCountUser", Age: 1})
+	DB.Save(&User{Name: "ComplexCountUser", Age: 2})
+	DB.Save(&User{Name: "ComplexCountUser2", Age: 2})
+	db := DB.Model(&User{}).Where("name LIKE ?", "ComplexCountUser%")
+
+	var count int
+	if err := db.Group("name").Count(&count).Error; err != nil || count != 2 {
+		t.Errorf("Count grouped query should get count of groups, but got %v, %v", count, err)
+	}
+
+	if err := db.Distinct("name").Count(&count).Error; err != nil || count != 2 {
+		t.Errorf("Count distinct query should get count of distinct names, but got %v, %v", count, err)
+	}
+
+	if err := db.Select("DISTINCT age").Count(&count).Error; err != nil || count != 2 {
+		t.Errorf("Count query selecting distinct should get count of distinct ages, but got %v, %v", count, err)
+	}
+
+	if err := db.Select("max(age)").Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("Count query selecting aggregate should get one row, but got %v, %v", count, err)
+	}
+
+	if err := db.Order("age").Limit(2).Offset(1).Count(&count).Error; err != nil || count != 2 {
+		t.Errorf("Count limited query should get count of limited records, but got %v, %v", count, err)
+	}
+
+	if err := db.Limit(10).Count(&count).Error; err != nil || count != 3 {
+		t.Errorf("Count limited query should get count of all records, but got %v, %v", count, err)
+	}
+}
+
+func TestDistinct(t *testing.T) {
+	DB.Save(&User{Name: "DistinctUser", Age: 1})
+	DB.Save(&User{Name: "DistinctUser", Age: 1})
+	DB.Save(&User{Name: "DistinctUser", Age: 2})
+	db := DB.Model(&User{}).Where("name = ?", "DistinctUser")
+
+	var users []User
+	if err := db.Distinct("name", "age").Order("age").Find(&users).Error; err != nil || len(users) != 2 || users[1].Age != 2 {
+		t.Errorf("Should find distinct users, but got %v, %v", len(users), err)
+	}
+
+	var ages []int64
+	if err := db.Distinct().Order("age").Pluck("age", &ages).Error; err != nil || len(ages) != 2 {
+		t.Errorf("Should pluck distinct ages, but got %v, %v", ages, err)
+	}
+
+	var count int
+	if err := db.Distinct("age").Count(&count).Error; err != nil || count != 2 {
+		t.Errorf("Should count distinct ages, but got %v, %v", count, err)
+	}
+}
+
+func TestExists(t *testing.T) {
+	user := User{Name: "ExistsUser", Emails: []Email{{Email: "exists_user@example.org"}}}
+	DB.Save(&user)
+	DB.Save(&User{Name: "ExistsUser2"})
+
+	if exists, err := DB.Model(&User{}).Where("name = ?", "ExistsUser").Exists(); err != nil || !exists {
+		t.Errorf("Should find existing user, but got %v, %v", exists, err)
+	}
+
+	if exists, err := DB.Model(&User{}).Where("name = ?", "NotExistsUser").Exists(); err != nil || exists {
+		t.Errorf("Should not find not existing user, but got %v, %v", exists, err)
+	}
+
+	if exists, err := DB.Model(&User{}).Joins("JOIN emails ON emails.user_id = users.id").Where("emails.email = ?", "exists_user@example.org").Exists(); err != nil || !exists {
+		t.Errorf("Should find existing user with joins, but got %v, %v", exists, err)
+	}
+
+	creditCard := CreditCard{Number: "ExistsCreditCard"}
+	DB.Save(&creditCard)
+	DB.Delete(&creditCard)
+	if exists, err := DB.Model(&CreditCard{}).Where("number = ?", creditCard.Number).Exists(); err != nil || exists {
+		t.Errorf("Should not find soft deleted record, but got %v, %v", exists, err)
+	}
+
+	if exists, err := DB.Unscoped().Model(&CreditCard{}).Where("number = ?", creditCard.Number).Exists(); err != nil || !exists {
+		t.Errorf("Should find soft deleted record with Unscoped, but got %v, %v", exists, err)
+	}
+
+	var users []User
+	hasEmails := DB.Model(&Email{}).Where("emails.user_id = users.id").ExistsExpr()
+	DB.Where("name IN (?)", []string{"ExistsUser", "ExistsUser2"}).Where(hasEmails).Find(&users)
+	if len(users) != 1 || users[0].Id != user.Id {
+		t.Errorf("Should find users having emails with exists sub query, but got %v", len(users))
+	}
+
+	DB.Where("name IN (?)", []string{"ExistsUser", "ExistsUser2"}).Not(hasEmails).Find(&users)
+	if len(users) != 1 || users[0].Name != "ExistsUser2" {
+		t.Errorf("Should find users without emails with not exists sub query, but got %v", len(users))
+	}
+
+	if exists, err := DB.Model(&User{}).Where(gorm.Expr("name = ?"), "ExistsUser2").Exists(); err != nil || !exists {
+		t.Errorf("Should use args passed with the expression, but got %v, %v", exists, err)
+	}
+
+	scopedDB := DB.Model(&User{}).Where("name IN (?)", []string{"ExistsUser", "ExistsUser2"}).Where(hasEmails)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var count int
+			if err := scopedDB.Count(&count).Error; err != nil || count != 1 {
+				t.Errorf("Should count users with shared expression conditions concurrently, but got %v, %v", count, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFindIntoMaps(t *testing.T) {
+	DB.Save(&User{Name: "MapUser", Age: 18})
+	DB.Save(&User{Name: "MapUser", Age: 20})
+
+	var results []map[string]interface{}
+	if err := DB.Model(&User{}).Where("name = ?", "MapUser").Order("age").Find(&results).Error; err != nil || len(results) != 2 {
+		t.Fatalf("Should find records into maps, but got %v, %v", len(results), err)
+	}
+
+	if name, ok := results[0]["name"].(string); !ok || name != "MapUser" {
+		t.Errorf("Textual column should be scanned as string, but got %#v", results[0]["name"])
+	}
+
+	if fmt.Sprint(results[1]["age"]) != "20" {
+		t.Errorf("Should find records into maps with right order, but got %v", results[1]["age"])
+	}
+
+	var pointers []*map[string]interface{}
+	if err := DB.Table("users").Where("name = ?", "MapUser").Find(&pointers).Error; err != nil || len(pointers) != 2 || (*pointers[0])["name"] != "MapUser" {
+		t.Errorf("Should find records into pointers of maps with table, but got %v, %v", len(pointers), err)
+	}
+
+	var result map[string]interface{}
+	if err := DB.Table("users").Where("name = ?", "MapUser").Select("name, age").Take(&result).Error; err != nil || len(result) != 2 || result["name"] != "MapUser" {
+		t.Errorf("Should take record into map, but got %v, %v", result, err)
+	}
+
+	if err := DB.Table("users").Where("name = ?", "NotExistingMapUser").Take(&result).Error; err != gorm.ErrRecordNotFound {
+		t.Errorf("Should get record not found error when no record found into map, but got %v", err)
+	}
+
+	rows, err := DB.Table("users").Where("name = ?", "MapUser").Select("name, age").Rows()
+	if err != nil {
+		t.Fatalf("Should get rows, but got %v", err)
+	}
+	defer rows.Close()
+
+	var count int
+	for rows.Next() {
+		row := map[string]interface{}{}
+		if err := DB.ScanRows(rows, &row); err != nil || row["name"] != "MapUser" {
+			t.Errorf("Should scan rows into map, but got %v, %v", row, err)
+		}
+		count++
+	}
+
+	if count != 2 {
+		t.Errorf("Should scan all rows, but got %v", count)
+	}
+
+	var invalid []map[int]interface{}
+	if err := DB.Table("users").Find(&invalid).Error; err == nil {
+		t.Errorf("Should get error when finding into map not keyed by string")
+	}
+}
+
+func TestScanNestedStruct(t *testing.T) {
+	company := Company{Name: "NestedScanCompany"}
+	DB.Save(&company)
+	companyID := int(company.Id)
+	user := User{Name: "NestedScanUser", CompanyID: &companyID}
+	DB.Save(&user)
+
+	type UserWithCompany struct {
+		User    User
+		Company *Company
+	}
+
+	db := DB.Model(&User{}).Joins("JOIN companies ON companies.id = users.company_id").Where("users.name = ?", user.Name)
+
+	var results []UserWithCompany
+	if err := db.Select(&results).Scan(&results).Error; err != nil || len(results) != 1 {
+		t.Fatalf("Should scan nested struct with select derived from it, but got %v, %v", len(results), err)
+	}
+
+	if results[0].User.Id != user.Id || results[0].User.Name != user.Name || results[0].Company == nil ||
+		results[0].Company.Id != company.Id || results[0].Company.Name != company.Name {
+		t.Errorf("Should scan columns into nested struct, but got %#v", results[0])
+	}
+
+	var result UserWithCompany
+	if err := db.Select("users.id AS user__id, companies.id AS companies__id, companies.name AS company__name").Scan(&result).Error; err != nil {
+		t.Fatalf("Should scan aliased columns into nested struct, but got %v", err)
+	}
+
+	if result.User.Id != user.Id || result.Company == nil || result.Company.Id != company.Id || result.Company.Name != company.Name {
+		t.Errorf("Should scan aliased columns with field name or table name into nested struct, but got %#v", result)
+	}
+}
+
+func TestSmartSelect(t *testing.T) {
+	company := Company{Name: "SmartSelectCompany"}
+	DB.Save(&company)
+	companyID := int(company.Id)
+	user := User{Name: "SmartSelectUser", Age: 20, CompanyID: &companyID, Emails: []Email{{Email: "smart_select@example.org"}}}
+	DB.Save(&user)
+
+	type UserDTO struct {
+		Id          int64
+		Name        string
+		CompanyName string `gorm:"column:companies.name"`
+		Unknown     string
+	}
+
+	db := DB.Set("gorm:smart_select", true).Where("users.name = ?", user.Name)
+
+	var results []UserDTO
+	if err := db.Model(&User{}).Joins("JOIN companies ON companies.id = users.company_id").Scan(&results).Error; err != nil || len(results) != 1 {
+		t.Fatalf("Should scan with smart select, but got %v, %v", len(results), err)
+	}
+
+	if results[0].Id != user.Id || results[0].Name != user.Name || results[0].CompanyName != company.Name {
+		t.Errorf("Should select fields of destination with smart select, but got %#v", results[0])
+	}
+
+	recorder := &sqlRecorder{}
+	db = db.New().LogMode(true).Set("gorm:smart_select", true).Where("users.name = ?", user.Name)
+	db.SetLogger(recorder)
+
+	var users []User
+	if err := db.Preload("Emails").Find(&users).Error; err != nil || len(users) != 1 || users[0].Age != 20 || len(users[0].Emails) != 1 {
+		t.Errorf("Should find and preload with smart select, but got %v, %v", len(users), err)
+	}
+
+	quote := DB.Dialect().Quote
+	if len(recorder.sqls) != 2 {
+		t.Fatalf("Should query users and preload emails, but got %v", recorder.sqls)
+	}
+
+	if sql := recorder.sqls[0]; strings.Contains(sql, "*") || !strings.Contains(sql, quote("users")+"."+quote("age")) {
+		t.Errorf("Should select columns of users with smart select, but got %v", sql)
+	}
+
+	if sql := recorder.sqls[1]; strings.Contains(sql, "*") || !strings.Contains(sql, quote("emails")+"."+quote("email")) || !strings.Contains(sql, quote("emails")+"."+quote("user_id")) {
+		t.Errorf("Should select columns of emails when preloading with smart select, but got %v", sql)
+	}
+}
+
+type sqlRecorder struct {
+	sqls []string
+}
+
+func (recorder *sqlRecorder) Print(values ...interface{}) {
+	if len(values) > 3 && values[0] == "sql" {
+		recorder.sqls = append(recorder.sqls, fmt.Sprint(values[3]))
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	type AggregateOrder struct {
+		gorm.Model
+		UserID uint
+		State  string
+		Amount int64
+		Price  float64
+	}
+	DB.DropTableIfExists(&AggregateOrder{})
+	DB.AutoMigrate(&AggregateOrder{})
+
+	DB.Save(&AggregateOrder{UserID: 1, State: "paid", Amount: 10, Price: 1})
+	DB.Save(&AggregateOrder{UserID: 1, State: "paid", Amount: 20, Price: 2})
+	DB.Save(&AggregateOrder{UserID: 2, State: "paid", Amount: 30, Price: 3})
+	DB.Save(&AggregateOrder{UserID: 2, State: "cancelled", Amount: 40, Price: 4})
+	deleted := AggregateOrder{UserID: 3, State: "paid", Amount: 50, Price: 5}
+	DB.Save(&deleted).Delete(&deleted)
+
+	var total int64
+	if err := DB.Model(&AggregateOrder{}).Where("state = ?", "paid").Sum("amount", &total).Error; err != nil || total != 60 {
+		t.Errorf("Sum should ignore soft deleted records, but got %v, %v", total, err)
+	}
+
+	total = 100
+	if err := DB.Model(&AggregateOrder{}).Where("state = ?", "unknown").Sum("Amount", &total).Error; err != nil || total != 0 {
+		t.Errorf("Sum of no records should be zero, but got %v, %v", total, err)
+	}
+
+	var avg float64
+	if DB.Model(&AggregateOrder{}).Avg("price", &avg); avg != 2.5 {
+		t.Errorf("Avg should be 2.5, but got %v", avg)
+	}
+
+	var min, max int64
+	if DB.Model(&AggregateOrder{}).Min("amount", &min).Max("amount", &max); min != 10 || max != 40 {
+		t.Errorf("Min and max should be 10 and 40, but got %v, %v", min, max)
+	}
+
+	var users int
+	if DB.Model(&AggregateOrder{}).CountDistinct("user_id", &users); users != 2 {
+		t.Errorf("Count distinct should be 2, but got %v", users)
+	}
+
+	var totals map[uint]int64
+	if err := DB.Model(&AggregateOrder{}).Where("state = ?", "paid").Group("user_id").Sum("amount", &totals).Error; err != nil || len(totals) != 2 || totals[1] != 30 || totals[2] != 30 {
+		t.Errorf("Grouped sum should fill map, but got %v, %v", totals, err)
+	}
+
+	var results []struct {
+		UserID uint
+		State  string
+		Max    int64
+	}
+	if err := DB.Model(&AggregateOrder{}).Group("user_id, state").Order("user_id, state").Max("amount", &results).Error; err != nil || len(results) != 3 {
+		t.Errorf("Grouped max should fill slice, but got %+v, %v", results, err)
+	} else if results[1].UserID != 2 || results[1].State != "cancelled" || results[1].Max != 40 {
+		t.Errorf("Grouped max should fill struct by group columns, but got %+v", results[1])
+	}
+
+	if err := DB.Model(&AggregateOrder{}).Group("user_id, state").Max("amount", &totals).Error; err == nil {
+		t.Errorf("Should got error when scanning multiple group columns into map")
+	}
+}
+
 func TestNot(t *testing.T) {
 	DB.Create(getPreparedUser("user1", "not"))
 	DB.Create(getPreparedUser("user2", "not"))
@@ -804,6 +1184,68 @@ func TestSelectWithArrayInput(t *testing.T) {
 	}
 }
 
+func TestPluckMapAndColumns(t *testing.T) {
+	user1 := User{Name: "PluckMapUser1", Age: 1}
+	user2 := User{Name: "PluckMapUser2", Age: 1}
+	DB.Save(&user1).Save(&user2)
+	db := DB.Model(&User{}).Where("name LIKE ?", "PluckMapUser%")
+
+	var names map[int64]string
+	if err := db.PluckMap("id", "name", &names).Error; err != nil || len(names) != 2 || names[user1.Id] != user1.Name || names[user2.Id] != user2.Name {
+		t.Errorf("Should pluck map, but got %v, %v", names, err)
+	}
+
+	var ids map[string]uint
+	if err := db.PluckMap("name", "id", &ids).Error; err != nil || ids[user2.Name] != uint(user2.Id) {
+		t.Errorf("Should pluck map with converted types, but got %v, %v", ids, err)
+	}
+
+	companyID := 7
+	DB.Model(&user1).Update("company_id", companyID)
+
+	var companies map[string]int
+	if err := db.PluckMap("name", "company_id", &companies).Error; err != nil || len(companies) != 2 || companies[user1.Name] != companyID || companies[user2.Name] != 0 {
+		t.Errorf("Should pluck map from pointer fields, but got %v, %v", companies, err)
+	}
+
+	var nullCompanies map[string]sql.NullInt64
+	if err := db.PluckMap("name", "company_id", &nullCompanies).Error; err != nil || nullCompanies[user1.Name].Int64 != int64(companyID) || nullCompanies[user2.Name].Valid {
+		t.Errorf("Should pluck map into scanners, but got %v, %v", nullCompanies, err)
+	}
+
+	var ages map[int]string
+	if err := db.PluckMap("age", "name", &ages).Error; err == nil {
+		t.Errorf("Should get error when plucking map with duplicate keys")
+	}
+
+	type result struct {
+		Id   uint
+		Name string
+	}
+
+	var results []result
+	if err := db.Order("id").PluckColumns(&results, "id", "name").Error; err != nil || len(results) != 2 || results[1].Name != user2.Name || results[1].Id != uint(user2.Id) {
+		t.Errorf("Should pluck columns, but got %v, %v", results, err)
+	}
+
+	var pointers []*result
+	if err := db.Order("id").PluckColumns(&pointers, "id", "name").Error; err != nil || len(pointers) != 2 || pointers[0].Name != user1.Name {
+		t.Errorf("Should pluck columns into pointers, but got %v, %v", len(pointers), err)
+	}
+
+	var companyResults []struct {
+		Name      string
+		CompanyID int
+	}
+	if err := db.Order("id").PluckColumns(&companyResults, "name", "company_id").Error; err != nil || len(companyResults) != 2 || companyResults[0].CompanyID != companyID || companyResults[1].CompanyID != 0 {
+		t.Errorf("Should pluck columns from pointer fields, but got %v, %v", companyResults, err)
+	}
+
+	if err := db.PluckColumns(&results, "id", "age").Error; err == nil {
+		t.Errorf("Should get error when plucking column not found in struct")
+	}
+}
+
 func TestPluckWithSelect(t *testing.T) {
 	var (
 		user              = User{Name: "matematik7_pluck_with_select", Age: 25}
@@ -2,14 +2,18 @@ package gorm
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/jinzhu/gorm/clause"
 )
 
 // Scope contain current operation's information when you perform any operation on the database
@@ -269,6 +273,10 @@ func (scope *Scope) AddToVars(value interface{}) string {
 		return exp
 	}
 
+	if array, ok := scope.postgresArray(value); ok {
+		value = array
+	}
+
 	scope.SQLVars = append(scope.SQLVars, value)
 
 	if skipBindVar {
@@ -327,6 +335,27 @@ func (scope *Scope) TableName() string {
 	return scope.GetModelStruct().TableName(scope.db.Model(scope.Value))
 }
 
+// IsRaw check if the scope is built with raw sql, e.g. `db.Raw(sql).Scan(&result)`
+func (scope *Scope) IsRaw() bool {
+	return scope.Search != nil && scope.Search.raw
+}
+
+// WhereConditions returns conditions added with `Where`, each condition is a map with the `query` and its `args`
+func (scope *Scope) WhereConditions() []map[string]interface{} {
+	if scope.Search == nil {
+		return nil
+	}
+	return scope.Search.whereConditions
+}
+
+// OrConditions returns conditions added with `Or`, each condition is a map with the `query` and its `args`
+func (scope *Scope) OrConditions() []map[string]interface{} {
+	if scope.Search == nil {
+		return nil
+	}
+	return scope.Search.orConditions
+}
+
 // QuotedTableName return quoted table name
 func (scope *Scope) QuotedTableName() (name string) {
 	if scope.Search != nil && len(scope.Search.tableName) > 0 {
@@ -361,7 +390,7 @@ func (scope *Scope) Exec() *Scope {
 	defer scope.trace(NowFunc())
 
 	if !scope.HasError() {
-		if result, err := scope.SQLDB().Exec(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
+		if result, err := scope.execSQL(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
 			if count, err := result.RowsAffected(); scope.Err(err) == nil {
 				scope.db.RowsAffected = count
 			}
@@ -381,6 +410,16 @@ func (scope *Scope) Get(name string) (interface{}, bool) {
 	return scope.db.Get(name)
 }
 
+// Context returns the context set with `db.WithContext`, or the background context
+func (scope *Scope) Context() context.Context {
+	if value, ok := scope.Get("gorm:context"); ok {
+		if ctx, ok := value.(context.Context); ok {
+			return ctx
+		}
+	}
+	return context.Background()
+}
+
 // InstanceID get InstanceID for scope
 func (scope *Scope) InstanceID() string {
 	if scope.instanceID == "" {
@@ -402,7 +441,7 @@ func (scope *Scope) InstanceGet(name string) (interface{}, bool) {
 // Begin start a transaction
 func (scope *Scope) Begin() *Scope {
 	if db, ok := scope.SQLDB().(sqlDb); ok {
-		if tx, err := db.Begin(); scope.Err(err) == nil {
+		if tx, err := db.BeginTx(scope.Context(), nil); scope.Err(err) == nil {
 			scope.db.db = interface{}(tx).(SQLCommon)
 			scope.InstanceSet("gorm:started_transaction", true)
 		}
@@ -429,6 +468,30 @@ func (scope *Scope) CommitOrRollback() *Scope {
 // Private Methods For *gorm.Scope
 ////////////////////////////////////////////////////////////////////////////////
 
+// execSQL executes the sql with the context set with `db.WithContext`, so it could be cancelled
+func (scope *Scope) execSQL(query string, args ...interface{}) (sql.Result, error) {
+	if db, ok := scope.SQLDB().(sqlCommonContext); ok {
+		return db.ExecContext(scope.Context(), query, args...)
+	}
+	return scope.SQLDB().Exec(query, args...)
+}
+
+// querySQL queries the sql with the context set with `db.WithContext`
+func (scope *Scope) querySQL(query string, args ...interface{}) (*sql.Rows, error) {
+	if db, ok := scope.SQLDB().(sqlCommonContext); ok {
+		return db.QueryContext(scope.Context(), query, args...)
+	}
+	return scope.SQLDB().Query(query, args...)
+}
+
+// queryRowSQL queries a row with the context set with `db.WithContext`
+func (scope *Scope) queryRowSQL(query string, args ...interface{}) *sql.Row {
+	if db, ok := scope.SQLDB().(sqlCommonContext); ok {
+		return db.QueryRowContext(scope.Context(), query, args...)
+	}
+	return scope.SQLDB().QueryRow(query, args...)
+}
+
 func (scope *Scope) callMethod(methodName string, reflectValue reflect.Value) {
 	// Only get address from non-pointer
 	if reflectValue.CanAddr() && reflectValue.Kind() != reflect.Ptr {
@@ -460,10 +523,11 @@ func (scope *Scope) callMethod(methodName string, reflectValue reflect.Value) {
 }
 
 var (
-	columnRegexp        = regexp.MustCompile("^[a-zA-Z\\d]+(\\.[a-zA-Z\\d]+)*$") // only match string like `name`, `users.name`
-	isNumberRegexp      = regexp.MustCompile("^\\s*\\d+\\s*$")                   // match if string is number
-	comparisonRegexp    = regexp.MustCompile("(?i) (=|<>|(>|<)(=?)|LIKE|IS|IN) ")
-	countingQueryRegexp = regexp.MustCompile("(?i)^count(.+)$")
+	columnRegexp         = regexp.MustCompile("^[a-zA-Z\\d]+(\\.[a-zA-Z\\d]+)*$") // only match string like `name`, `users.name`
+	isNumberRegexp       = regexp.MustCompile("^\\s*\\d+\\s*$")                   // match if string is number
+	comparisonRegexp     = regexp.MustCompile("(?i) (=|<>|(>|<)(=?)|LIKE|IS|IN) ")
+	countingQueryRegexp  = regexp.MustCompile("(?i)^count(.+)$")
+	computedSelectRegexp = regexp.MustCompile("(?i)(\\bdistinct\\b|\\b(count|sum|avg|min|max)\\s*\\()") // match select with distinct or aggregate functions
 )
 
 func (scope *Scope) quoteIfPossible(str string) string {
@@ -480,8 +544,36 @@ func (scope *Scope) scan(rows *sql.Rows, columns []string, fields []*Field) {
 		selectFields       []*Field
 		selectedColumnsMap = map[string]int{}
 		resetFields        = map[int]*Field{}
+		encryptedFields    []*Field
+		serializedFields   = map[int]*Field{}
 	)
 
+	scanField := func(index int, field *Field) {
+		if isEncryptedField(field.StructField) {
+			encryptedFields = append(encryptedFields, field)
+		}
+
+		if _, ok := field.serializer(); ok {
+			values[index] = new(interface{})
+			serializedFields[index] = field
+			return
+		}
+
+		if array, ok := scope.postgresArray(field.Field.Addr().Interface()); ok {
+			values[index] = array
+			return
+		}
+
+		if field.Field.Kind() == reflect.Ptr {
+			values[index] = field.Field.Addr().Interface()
+		} else {
+			reflectValue := reflect.New(reflect.PtrTo(field.Struct.Type))
+			reflectValue.Elem().Set(field.Field.Addr())
+			values[index] = reflectValue.Interface()
+			resetFields[index] = field
+		}
+	}
+
 	for index, column := range columns {
 		values[index] = &ignored
 
@@ -494,15 +586,7 @@ func (scope *Scope) scan(rows *sql.Rows, columns []string, fields []*Field) {
 
 		for fieldIndex, field := range selectFields {
 			if field.DBName == column {
-				if field.Field.Kind() == reflect.Ptr {
-					values[index] = field.Field.Addr().Interface()
-				} else {
-					reflectValue := reflect.New(reflect.PtrTo(field.Struct.Type))
-					reflectValue.Elem().Set(field.Field.Addr())
-					values[index] = reflectValue.Interface()
-					resetFields[index] = field
-				}
-
+				scanField(index, field)
 				selectedColumnsMap[column] = offset + fieldIndex
 
 				if field.IsNormal {
@@ -510,6 +594,13 @@ func (scope *Scope) scan(rows *sql.Rows, columns []string, fields []*Field) {
 				}
 			}
 		}
+
+		if _, ok := selectedColumnsMap[column]; !ok {
+			// aliased column of nested struct, e.g: `company__name`
+			if field := scope.nestedField(fields, column); field != nil {
+				scanField(index, field)
+			}
+		}
 	}
 
 	scope.Err(rows.Scan(values...))
@@ -519,6 +610,169 @@ func (scope *Scope) scan(rows *sql.Rows, columns []string, fields []*Field) {
 			field.Field.Set(v)
 		}
 	}
+
+	for index, field := range serializedFields {
+		serializer, _ := field.serializer()
+		if scope.Err(field.deserialize(serializer, *values[index].(*interface{}))) != nil {
+			return
+		}
+	}
+
+	for _, field := range encryptedFields {
+		if scope.Err(scope.decryptField(field)) != nil {
+			return
+		}
+	}
+}
+
+// nestedField find the field of nested struct for aliased column like `company__name` or `companies.name`,
+// the prefix could be the snake case name of the struct field, or the table name of the nested struct
+func (scope *Scope) nestedField(fields []*Field, column string) *Field {
+	var prefix, name string
+	if idx := strings.Index(column, "__"); idx > 0 {
+		prefix, name = column[:idx], column[idx+2:]
+	} else if idx := strings.Index(column, "."); idx > 0 {
+		prefix, name = column[:idx], column[idx+1:]
+	} else {
+		return nil
+	}
+
+	for _, field := range fields {
+		// field of anonymous embedded struct
+		if len(field.Names) > 1 && field.DBName == name && ToColumnName(field.Names[0]) == prefix {
+			return field
+		}
+	}
+
+	for _, field := range fields {
+		if field.IsNormal || field.IsIgnored {
+			continue
+		}
+
+		fieldType := field.Struct.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() != reflect.Struct || (ToColumnName(field.Name) != prefix && scope.New(reflect.New(fieldType).Interface()).TableName() != prefix) {
+			continue
+		}
+
+		fieldValue := field.Field
+		if fieldValue.Kind() == reflect.Ptr {
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(fieldType))
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		nestedFields := scope.New(fieldValue.Addr().Interface()).Fields()
+		for _, nestedField := range nestedFields {
+			if nestedField.DBName == name {
+				return nestedField
+			}
+		}
+		return scope.nestedField(nestedFields, name)
+	}
+	return nil
+}
+
+// nestedSelectSQL build select columns for the struct, columns of nested structs are aliased like `company__name`, which could be scanned back with `Scan`
+func (scope *Scope) nestedSelectSQL(structType reflect.Type) string {
+	var modelFields []*StructField
+	if scope.Value != nil {
+		modelFields = scope.GetModelStruct().StructFields
+	}
+
+	var (
+		columns    []string
+		destFields = scope.New(reflect.New(structType).Interface()).GetModelStruct().StructFields
+	)
+	for _, field := range destFields {
+		if field.IsNormal && !field.IsIgnored {
+			column := scope.Quote(field.DBName)
+			for _, modelField := range modelFields {
+				if modelField.IsNormal && modelField.DBName == field.DBName {
+					column = scope.QuotedTableName() + "." + column
+					break
+				}
+			}
+			columns = append(columns, column)
+		}
+	}
+
+	// nested structs are selected from their tables, which should be joined
+	for _, field := range destFields {
+		fieldType := field.Struct.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.IsNormal || field.IsIgnored || fieldType.Kind() != reflect.Struct {
+			continue
+		}
+
+		nestedScope := scope.New(reflect.New(fieldType).Interface())
+		for _, nestedField := range nestedScope.GetModelStruct().StructFields {
+			if nestedField.IsNormal && !nestedField.IsIgnored {
+				columns = append(columns, fmt.Sprintf("%v.%v AS %v", nestedScope.QuotedTableName(), scope.Quote(nestedField.DBName), scope.Quote(ToColumnName(field.Name)+"__"+nestedField.DBName)))
+			}
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+// smartSelectColumns compute columns to select for the destination struct, which are its fields that also exist in the model,
+// fields having qualified column names like `gorm:"column:companies.name"` are selected from the qualified tables
+func (scope *Scope) smartSelectColumns(destType reflect.Type) (columns []string) {
+	modelFields := map[string]bool{}
+	for _, field := range scope.GetModelStruct().StructFields {
+		if field.IsNormal && !field.IsIgnored {
+			modelFields[field.DBName] = true
+		}
+	}
+
+	for _, field := range scope.New(reflect.New(destType).Interface()).GetModelStruct().StructFields {
+		if !field.IsNormal || field.IsIgnored {
+			continue
+		}
+
+		if strings.Contains(field.DBName, ".") {
+			columns = append(columns, fmt.Sprintf("%v AS %v", scope.Quote(field.DBName), scope.Dialect().Quote(field.DBName)))
+		} else if modelFields[field.DBName] || scope.Value == nil {
+			columns = append(columns, scope.QuotedTableName()+"."+scope.Quote(field.DBName))
+		}
+	}
+	return
+}
+
+// scanMap scan current row into the map keyed by column names, []byte values of textual (non binary) columns are converted to string
+func (scope *Scope) scanMap(rows *sql.Rows, columns []string, dest reflect.Value) {
+	if mapType := dest.Type(); mapType.Key().Kind() != reflect.String || mapType.Elem().Kind() != reflect.Interface || mapType.Elem().NumMethod() != 0 {
+		scope.Err(fmt.Errorf("unsupported map destination %v, should be map[string]interface{}", mapType))
+		return
+	}
+
+	var (
+		columnTypes, _ = rows.ColumnTypes()
+		values         = make([]interface{}, len(columns))
+	)
+
+	for index := range columns {
+		values[index] = new(interface{})
+	}
+
+	if scope.Err(rows.Scan(values...)) != nil {
+		return
+	}
+
+	for index, column := range columns {
+		value := *values[index].(*interface{})
+		if b, ok := value.([]byte); ok && (index >= len(columnTypes) || !isBinaryColumnType(columnTypes[index].DatabaseTypeName())) {
+			value = string(b)
+		}
+		dest.SetMapIndex(reflect.ValueOf(column).Convert(dest.Type().Key()), reflect.ValueOf(&value).Elem())
+	}
 }
 
 func (scope *Scope) primaryCondition(value interface{}) string {
@@ -539,6 +793,19 @@ func (scope *Scope) buildCondition(clause map[string]interface{}, include bool)
 		inSQL = "NOT IN"
 	}
 
+	if sql, ok := scope.buildExpression(clause["query"]); ok {
+		if sql != "" {
+			if !include {
+				return fmt.Sprintf("NOT (%v)", sql)
+			}
+			return fmt.Sprintf("(%v)", sql)
+		}
+		return
+	}
+
+	// clause is shared by cloned DBs, so args are never written back to it
+	args, _ := clause["args"].([]interface{})
+
 	switch value := clause["query"].(type) {
 	case sql.NullInt64:
 		return fmt.Sprintf("(%v.%v %s %v)", quotedTableName, quotedPrimaryKey, equalSQL, value.Int64)
@@ -549,12 +816,16 @@ func (scope *Scope) buildCondition(clause map[string]interface{}, include bool)
 			return
 		}
 		str = fmt.Sprintf("(%v.%v %s (?))", quotedTableName, quotedPrimaryKey, inSQL)
-		clause["args"] = []interface{}{value}
+		args = []interface{}{value}
 	case string:
 		if isNumberRegexp.MatchString(value) {
 			return fmt.Sprintf("(%v.%v %s %v)", quotedTableName, quotedPrimaryKey, equalSQL, scope.AddToVars(value))
 		}
 
+		if !scope.IsRaw() && !scope.checkEncryptedColumnCondition(value) {
+			return
+		}
+
 		if value != "" {
 			if !include {
 				if comparisonRegexp.MatchString(value) {
@@ -566,10 +837,26 @@ func (scope *Scope) buildCondition(clause map[string]interface{}, include bool)
 				str = fmt.Sprintf("(%v)", value)
 			}
 		}
+	case *SqlExpr:
+		if !include {
+			str = fmt.Sprintf("NOT (%v)", value.expr)
+		} else {
+			str = fmt.Sprintf("(%v)", value.expr)
+		}
+		// args passed with the expression are appended to its own args
+		args = append(append([]interface{}{}, value.args...), args...)
 	case map[string]interface{}:
 		var sqls []string
 		for key, value := range value {
 			if value != nil {
+				if field, ok := scope.FieldByName(key); ok && isEncryptedField(field.StructField) {
+					sql, ok := scope.blindIndexCondition(scope, field, value, quotedTableName, equalSQL)
+					if !ok {
+						return
+					}
+					sqls = append(sqls, sql)
+					continue
+				}
 				sqls = append(sqls, fmt.Sprintf("(%v.%v %s %v)", quotedTableName, scope.Quote(key), equalSQL, scope.AddToVars(value)))
 			} else {
 				if !include {
@@ -591,7 +878,15 @@ func (scope *Scope) buildCondition(clause map[string]interface{}, include bool)
 		scopeQuotedTableName := newScope.QuotedTableName()
 		for _, field := range newScope.Fields() {
 			if !field.IsIgnored && !field.IsBlank {
-				sqls = append(sqls, fmt.Sprintf("(%v.%v %s %v)", scopeQuotedTableName, scope.Quote(field.DBName), equalSQL, scope.AddToVars(field.Field.Interface())))
+				if isEncryptedField(field.StructField) {
+					sql, ok := scope.blindIndexCondition(newScope, field, field.Field.Interface(), scopeQuotedTableName, equalSQL)
+					if !ok {
+						return
+					}
+					sqls = append(sqls, sql)
+					continue
+				}
+				sqls = append(sqls, fmt.Sprintf("(%v.%v %s %v)", scopeQuotedTableName, scope.Quote(field.DBName), equalSQL, scope.AddToVars(field.serialize(field.Field.Interface()))))
 			}
 		}
 		return strings.Join(sqls, " AND ")
@@ -601,8 +896,25 @@ func (scope *Scope) buildCondition(clause map[string]interface{}, include bool)
 	}
 
 	replacements := []string{}
-	args := clause["args"].([]interface{})
+	if strings.Contains(str, "@") {
+		if namedArgs, ok := scope.namedArgs(args); ok {
+			// a map or struct is used as named arguments only if any `@name` is bound, so it could still be used with operators like `@>`
+			namedStr, namedValues, missing := scope.bindNamedArgs(str, namedArgs)
+			if _, isNamedArg := args[0].(sql.NamedArg); isNamedArg || len(namedValues) > 0 {
+				for _, name := range missing {
+					scope.Err(fmt.Errorf("named parameter @%v not found", name))
+				}
+				str, args = namedStr, namedValues
+			}
+		}
+	}
+
 	for _, arg := range args {
+		if sql, ok := scope.buildExpression(arg); ok {
+			replacements = append(replacements, sql)
+			continue
+		}
+
 		var err error
 		switch reflect.ValueOf(arg).Kind() {
 		case reflect.Slice: // For where("id in (?)", []int64{1,2})
@@ -665,12 +977,132 @@ func (scope *Scope) buildCondition(clause map[string]interface{}, include bool)
 	return
 }
 
+// clauseBuilder build clause expressions for current scope, field names will be mapped to db names
+type clauseBuilder struct {
+	scope *Scope
+}
+
+func (builder clauseBuilder) Column(name string) string {
+	if strings.ContainsAny(name, "( ") {
+		return name
+	}
+
+	if !strings.Contains(name, ".") {
+		if field, ok := builder.scope.FieldByName(name); ok {
+			name = field.DBName
+		}
+	}
+	return builder.scope.Quote(name)
+}
+
+func (builder clauseBuilder) AddVar(value interface{}) string {
+	return builder.scope.AddToVars(value)
+}
+
+// buildExpression build value into SQL if it is a clause expression
+func (scope *Scope) buildExpression(value interface{}) (string, bool) {
+	if expression, ok := value.(clause.Expression); ok {
+		return expression.Build(clauseBuilder{scope}), true
+	}
+	return "", false
+}
+
+// namedArgs return named arguments' values if args are `sql.NamedArg`s, a `map[string]interface{}` or a struct
+func (scope *Scope) namedArgs(args []interface{}) (map[string]interface{}, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+
+	if len(args) == 1 {
+		switch value := args[0].(type) {
+		case map[string]interface{}:
+			return value, true
+		case driver.Valuer, time.Time, *time.Time, sql.NamedArg, *SqlExpr, clause.Expression:
+		default:
+			if reflectValue := indirect(reflect.ValueOf(value)); reflectValue.Kind() == reflect.Struct {
+				results := map[string]interface{}{}
+				for _, field := range scope.New(value).Fields() {
+					if field.Field.IsValid() {
+						results[field.DBName] = field.Field.Interface()
+						results[field.Name] = field.Field.Interface()
+					}
+				}
+				return results, true
+			}
+			return nil, false
+		}
+	}
+
+	results := map[string]interface{}{}
+	for _, arg := range args {
+		namedArg, ok := arg.(sql.NamedArg)
+		if !ok {
+			return nil, false
+		}
+		results[namedArg.Name] = namedArg.Value
+	}
+	return results, true
+}
+
+// bindNamedArgs replace `@name` in str with positional placeholders, return positional args in order and names not found, repeated names reuse the same value
+func (scope *Scope) bindNamedArgs(str string, namedArgs map[string]interface{}) (string, []interface{}, []string) {
+	var (
+		args    []interface{}
+		missing []string
+		buff    = bytes.NewBuffer([]byte{})
+		quoted  bool
+		isIdent = func(r byte) bool {
+			return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		}
+	)
+
+	for i := 0; i < len(str); i++ {
+		char := str[i]
+		if char == '\'' {
+			quoted = !quoted
+		}
+
+		// skip quoted strings, `@@` variables and emails like `a@b`
+		if char != '@' || quoted || (i > 0 && (isIdent(str[i-1]) || str[i-1] == '@')) || i+1 >= len(str) || str[i+1] == '@' || !isIdent(str[i+1]) {
+			buff.WriteByte(char)
+			continue
+		}
+
+		end := i + 1
+		for end < len(str) && isIdent(str[end]) {
+			end++
+		}
+
+		name := str[i+1 : end]
+		if value, ok := namedArgs[name]; ok {
+			buff.WriteString("?")
+			args = append(args, value)
+		} else {
+			missing = append(missing, name)
+			buff.WriteString(str[i:end])
+		}
+		i = end - 1
+	}
+	return buff.String(), args, missing
+}
+
 func (scope *Scope) buildSelectQuery(clause map[string]interface{}) (str string) {
 	switch value := clause["query"].(type) {
 	case string:
 		str = value
 	case []string:
 		str = strings.Join(value, ", ")
+	default:
+		// select columns of the destination struct
+		if structType := reflect.TypeOf(value); structType != nil {
+			for structType.Kind() == reflect.Slice || structType.Kind() == reflect.Ptr {
+				structType = structType.Elem()
+			}
+
+			if structType.Kind() == reflect.Struct {
+				str = scope.nestedSelectSQL(structType)
+			}
+		}
 	}
 
 	args := clause["args"].([]interface{})
@@ -767,13 +1199,18 @@ func (scope *Scope) whereSQL() (sql string) {
 }
 
 func (scope *Scope) selectSQL() string {
+	var distinctSQL string
+	if scope.Search.distinct {
+		distinctSQL = "DISTINCT "
+	}
+
 	if len(scope.Search.selects) == 0 {
 		if len(scope.Search.joinConditions) > 0 {
-			return fmt.Sprintf("%v.*", scope.QuotedTableName())
+			return fmt.Sprintf("%v%v.*", distinctSQL, scope.QuotedTableName())
 		}
-		return "*"
+		return distinctSQL + "*"
 	}
-	return scope.buildSelectQuery(scope.Search.selects)
+	return distinctSQL + scope.buildSelectQuery(scope.Search.selects)
 }
 
 func (scope *Scope) orderSQL() string {
@@ -791,6 +1228,8 @@ func (scope *Scope) orderSQL() string {
 				exp = strings.Replace(exp, "?", scope.AddToVars(arg), 1)
 			}
 			orders = append(orders, exp)
+		} else if sql, ok := scope.buildExpression(order); ok {
+			orders = append(orders, sql)
 		}
 	}
 	return " ORDER BY " + strings.Join(orders, ",")
@@ -840,11 +1279,107 @@ func (scope *Scope) joinsSQL() string {
 	return strings.Join(joinConditions, " ") + " "
 }
 
+// subQueryExpr convert `*DB`, `*SqlExpr` or string to a sql expression that could be embedded into current query
+func (scope *Scope) subQueryExpr(query interface{}) *SqlExpr {
+	switch value := query.(type) {
+	case *SqlExpr:
+		return value
+	case *DB:
+		return value.QueryExpr()
+	case string:
+		return Expr(value)
+	}
+	scope.Err(fmt.Errorf("invalid sub query: %v", query))
+	return nil
+}
+
+func (scope *Scope) compoundSQL() string {
+	var (
+		sqlite3 sqlite3
+		sqls    []string
+	)
+	for _, compound := range scope.Search.compounds {
+		expr := scope.subQueryExpr(compound.query)
+		if expr == nil {
+			continue
+		}
+
+		// sqlite doesn't allow parentheses around compound select members
+		sql := scope.AddToVars(expr)
+		if scope.Dialect().GetName() != sqlite3.GetName() {
+			sql = "(" + sql + ")"
+		}
+		if compound.operator != "" {
+			sql = compound.operator + " " + sql
+		}
+		sqls = append(sqls, sql)
+	}
+	return strings.Join(sqls, " ")
+}
+
+// fromSQL return the source of current query, sub queries and compound queries are used as a derived table named with current table name
+func (scope *Scope) fromSQL() string {
+	if from := scope.Search.from; from != nil {
+		if expr := scope.subQueryExpr(from.query); expr != nil {
+			return fmt.Sprintf("(%v) AS %v", scope.AddToVars(expr), scope.QuotedTableName())
+		}
+	}
+
+	if len(scope.Search.compounds) > 0 {
+		alias := scope.QuotedTableName()
+		if scope.TableName() == "" {
+			alias = scope.Quote("compound_table")
+		}
+		return fmt.Sprintf("(%v) AS %v", scope.compoundSQL(), alias)
+	}
+
+	if asOf, ok := scope.Get("gorm:as_of"); ok && scope.historical() {
+		if t, ok := asOf.(time.Time); ok {
+			return fmt.Sprintf("(%v) AS %v", scope.asOfSQL(t), scope.QuotedTableName())
+		}
+	}
+	return scope.QuotedTableName()
+}
+
+func (scope *Scope) withSQL() string {
+	if len(scope.Search.ctes) == 0 {
+		return ""
+	}
+
+	var (
+		postgres postgres
+		ctes     []string
+	)
+	for _, cte := range scope.Search.ctes {
+		expr := scope.subQueryExpr(cte.query)
+		if expr == nil {
+			continue
+		}
+
+		var hint string
+		if len(cte.materialized) > 0 && scope.Dialect().GetName() == postgres.GetName() {
+			if cte.materialized[0] {
+				hint = "MATERIALIZED "
+			} else {
+				hint = "NOT MATERIALIZED "
+			}
+		}
+		ctes = append(ctes, fmt.Sprintf("%v AS %v(%v)", scope.quoteIfPossible(cte.name), hint, scope.AddToVars(expr)))
+	}
+	return "WITH " + strings.Join(ctes, ", ") + " "
+}
+
 func (scope *Scope) prepareQuerySQL() {
+	// common table expressions are rendered first, so their vars come before the select's vars
+	withSQL := scope.withSQL()
 	if scope.Search.raw {
-		scope.Raw(scope.CombinedConditionSql())
+		scope.Raw(withSQL + scope.CombinedConditionSql())
+	} else if _, ok := scope.InstanceGet("gorm:count_sub_query"); ok {
+		scope.Raw(fmt.Sprintf("%vSELECT count(*) FROM (SELECT %v FROM %v %v) AS count_table", withSQL, scope.selectSQL(), scope.fromSQL(), scope.CombinedConditionSql()))
+	} else if _, ok := scope.InstanceGet("gorm:exists_query"); ok {
+		scope.Raw(strings.TrimSpace(fmt.Sprintf("%vSELECT CASE WHEN EXISTS (SELECT %v FROM %v %v) THEN 1 ELSE 0 END %v", withSQL, scope.selectSQL(), scope.fromSQL(), scope.CombinedConditionSql(), scope.Dialect().SelectFromDummyTable())))
 	} else {
-		scope.Raw(fmt.Sprintf("SELECT %v FROM %v %v", scope.selectSQL(), scope.QuotedTableName(), scope.CombinedConditionSql()))
+		scope.Raw(fmt.Sprintf("%vSELECT %v FROM %v %v", withSQL, scope.selectSQL(), scope.fromSQL(), scope.CombinedConditionSql()))
 	}
 	return
 }
@@ -994,13 +1529,150 @@ func (scope *Scope) pluck(column string, value interface{}) *Scope {
 		scope.Search.Select(column)
 	}
 
+	return scope.pluckRows(func(rows *sql.Rows, columns []string) {
+		elem := reflect.New(dest.Type().Elem()).Interface()
+		scope.Err(rows.Scan(elem))
+		dest.Set(reflect.Append(dest, reflect.ValueOf(elem).Elem()))
+	})
+}
+
+func (scope *Scope) pluckMap(keyColumn, valueColumn string, value interface{}) *Scope {
+	dest := reflect.Indirect(reflect.ValueOf(value))
+	if dest.Kind() != reflect.Map || !dest.CanSet() {
+		scope.Err(fmt.Errorf("results should be a pointer of map, not %s", dest.Kind()))
+		return scope
+	}
+
+	dest.Set(reflect.MakeMap(dest.Type()))
+
+	if _, ok := scope.Search.selects["query"]; !ok {
+		scope.Search.Select(keyColumn + ", " + valueColumn)
+	}
+
+	return scope.pluckRows(func(rows *sql.Rows, columns []string) {
+		if len(columns) != 2 {
+			scope.Err(fmt.Errorf("expected 2 columns when plucking map, but got %v", len(columns)))
+			return
+		}
+
+		key, elem := reflect.New(dest.Type().Key()).Elem(), reflect.New(dest.Type().Elem()).Elem()
+		scope.pluckRow(rows, columns, []*Field{
+			{StructField: &StructField{Name: columns[0], DBName: columns[0], IsNormal: true, Struct: reflect.StructField{Type: key.Type()}}, Field: key},
+			{StructField: &StructField{Name: columns[1], DBName: columns[1], IsNormal: true, Struct: reflect.StructField{Type: elem.Type()}}, Field: elem},
+		})
+
+		if !scope.HasError() {
+			if dest.MapIndex(key).IsValid() {
+				scope.Err(fmt.Errorf("duplicate key %v of column %v when plucking map", key.Interface(), keyColumn))
+				return
+			}
+			dest.SetMapIndex(key, elem)
+		}
+	})
+}
+
+func (scope *Scope) pluckColumns(value interface{}, columns ...string) *Scope {
+	dest := reflect.Indirect(reflect.ValueOf(value))
+	if dest.Kind() != reflect.Slice {
+		scope.Err(fmt.Errorf("results should be a slice, not %s", dest.Kind()))
+		return scope
+	}
+
+	isPtr, elemType := false, dest.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		isPtr, elemType = true, elemType.Elem()
+	}
+
+	if elemType.Kind() != reflect.Struct {
+		scope.Err(fmt.Errorf("results should be a slice of struct, not %s", elemType.Kind()))
+		return scope
+	}
+
+	dest.Set(reflect.MakeSlice(dest.Type(), 0, 0))
+
+	if _, ok := scope.Search.selects["query"]; !ok && len(columns) > 0 {
+		scope.Search.Select(strings.Join(columns, ", "))
+	}
+
+	return scope.pluckRows(func(rows *sql.Rows, columns []string) {
+		elem := reflect.New(elemType)
+		fields := scope.New(elem.Interface()).Fields()
+
+		dests := make([]*Field, len(columns))
+		for idx, column := range columns {
+			if dests[idx] = normalFieldByDBName(fields, column); dests[idx] == nil {
+				scope.Err(fmt.Errorf("column %v not found in %v when plucking columns", column, elemType))
+				return
+			}
+		}
+
+		scope.pluckRow(rows, columns, dests)
+		if isPtr {
+			dest.Set(reflect.Append(dest, elem))
+		} else {
+			dest.Set(reflect.Append(dest, elem.Elem()))
+		}
+	})
+}
+
+func normalFieldByDBName(fields []*Field, dbName string) *Field {
+	for _, field := range fields {
+		if field.DBName == dbName && field.IsNormal && !field.IsIgnored {
+			return field
+		}
+	}
+	return nil
+}
+
+// pluckRow scan a row into a new record of current model, then set values of its fields to dests with the conversion rules of `Field.Set`,
+// so scanners, serializers and pointers of the model are respected, columns not belong to the model are scanned into dests directly
+func (scope *Scope) pluckRow(rows *sql.Rows, columns []string, dests []*Field) {
+	var (
+		fields      []*Field
+		modelFields = map[int]*Field{}
+	)
+
+	if modelType := scope.GetModelStruct().ModelType; modelType != nil {
+		fields = scope.New(reflect.New(modelType).Interface()).Fields()
+	}
+
+	for idx, column := range columns {
+		if field := normalFieldByDBName(fields, column); field != nil {
+			modelFields[idx] = field
+		} else {
+			fields = append(fields, dests[idx])
+		}
+	}
+
+	if scope.scan(rows, columns, fields); scope.HasError() {
+		return
+	}
+
+	for idx, field := range modelFields {
+		value := field.Field
+		if value.Kind() == reflect.Ptr && dests[idx].Field.Kind() != reflect.Ptr {
+			if value.IsNil() {
+				value = reflect.Value{}
+			} else {
+				value = value.Elem()
+			}
+		}
+
+		if scope.Err(dests[idx].Set(value)) != nil {
+			return
+		}
+	}
+}
+
+// pluckRows query with current conditions, scan each row of the results with the given function until any error happens
+func (scope *Scope) pluckRows(scanRow func(rows *sql.Rows, columns []string)) *Scope {
 	rows, err := scope.rows()
 	if scope.Err(err) == nil {
 		defer rows.Close()
-		for rows.Next() {
-			elem := reflect.New(dest.Type().Elem()).Interface()
-			scope.Err(rows.Scan(elem))
-			dest.Set(reflect.Append(dest, reflect.ValueOf(elem).Elem()))
+
+		columns, err := rows.Columns()
+		for scope.Err(err) == nil && !scope.HasError() && rows.Next() {
+			scanRow(rows, columns)
 		}
 
 		if err := rows.Err(); err != nil {
@@ -1012,25 +1684,144 @@ func (scope *Scope) pluck(column string, value interface{}) *Scope {
 
 func (scope *Scope) count(value interface{}) *Scope {
 	if query, ok := scope.Search.selects["query"]; !ok || !countingQueryRegexp.MatchString(fmt.Sprint(query)) {
-		if len(scope.Search.group) != 0 {
-			if len(scope.Search.havingConditions) != 0 {
-				scope.prepareQuerySQL()
-				scope.Search = &search{}
-				scope.Search.Select("count(*)")
-				scope.Search.Table(fmt.Sprintf("( %s ) AS count_table", scope.SQL))
-			} else {
-				scope.Search.Select("count(*) FROM ( SELECT count(*) as name ")
-				scope.Search.group += " ) AS count_table"
+		limitAndOffsetSQL, _ := scope.Dialect().LimitAndOffsetSQL(scope.Search.limit, scope.Search.offset)
+
+		if !scope.Search.raw && (len(scope.Search.group) != 0 || scope.Search.distinct || limitAndOffsetSQL != "" || (ok && computedSelectRegexp.MatchString(fmt.Sprint(query)))) {
+			// count grouped, distinct, limited or computed results by wrapping current query as a sub query
+			if !ok && !scope.Search.distinct && len(scope.Search.group) != 0 {
+				scope.Search.Select(scope.Search.group)
 			}
-		} else {
-			scope.Search.Select("count(*)")
+			// keep orders of limited query, as they decide which records will be counted
+			scope.Search.ignoreOrderQuery = limitAndOffsetSQL == ""
+			scope.InstanceSet("gorm:count_sub_query", true)
+			scope.Err(scope.row().Scan(value))
+			return scope
 		}
+		scope.Search.Select("count(*)")
 	}
 	scope.Search.ignoreOrderQuery = true
 	scope.Err(scope.row().Scan(value))
 	return scope
 }
 
+func (scope *Scope) exists(value *bool) *Scope {
+	var result int
+	scope.prepareExistsQuery()
+	scope.InstanceSet("gorm:exists_query", true)
+	if scope.Err(scope.row().Scan(&result)) == nil {
+		*value = result == 1
+	}
+	return scope
+}
+
+// prepareExistsQuery select constant value instead of columns, as only existence of the records matters
+func (scope *Scope) prepareExistsQuery() {
+	if len(scope.Search.selects) == 0 && !scope.Search.distinct {
+		scope.Search.Select("1")
+	}
+	// keep orders of limited query, as they decide which records will be checked
+	limitAndOffsetSQL, _ := scope.Dialect().LimitAndOffsetSQL(scope.Search.limit, scope.Search.offset)
+	scope.Search.ignoreOrderQuery = limitAndOffsetSQL == ""
+}
+
+// aggregate query aggregate function of the column, scan NULL as zero value, results of grouped queries will be scanned into a map keyed by the group column or a slice of struct
+func (scope *Scope) aggregate(function, column string, value interface{}) *Scope {
+	dest := reflect.ValueOf(value)
+	if dest.Kind() != reflect.Ptr || dest.IsNil() {
+		scope.Err(fmt.Errorf("aggregate destination should be a pointer, not %v", dest.Kind()))
+		return scope
+	}
+	dest = dest.Elem()
+
+	var (
+		alias = strings.ToLower(function)
+		expr  = fmt.Sprintf("%v(%v)", function, clauseBuilder{scope}.Column(column))
+	)
+	if function == "COUNT DISTINCT" {
+		alias = "count"
+		expr = fmt.Sprintf("COUNT(DISTINCT %v)", clauseBuilder{scope}.Column(column))
+	}
+
+	if len(scope.Search.group) == 0 {
+		scope.Search.Select(expr)
+		scope.Search.ignoreOrderQuery = true
+
+		result := reflect.New(reflect.PtrTo(dest.Type()))
+		if scope.Err(scope.row().Scan(result.Interface())) == nil {
+			if result.Elem().IsNil() {
+				dest.Set(reflect.Zero(dest.Type()))
+			} else {
+				dest.Set(result.Elem().Elem())
+			}
+		}
+		return scope
+	}
+
+	scope.Search.Select(fmt.Sprintf("%v, %v AS %v", scope.Search.group, expr, scope.Quote(alias)))
+
+	switch dest.Kind() {
+	case reflect.Map:
+		if strings.Contains(scope.Search.group, ",") {
+			scope.Err(errors.New("grouped aggregate results with multiple group columns should be scanned into a slice of struct"))
+			return scope
+		}
+		if dest.IsNil() {
+			dest.Set(reflect.MakeMap(dest.Type()))
+		}
+
+		rows, err := scope.rows()
+		if scope.Err(err) == nil {
+			defer rows.Close()
+			for rows.Next() {
+				key := reflect.New(dest.Type().Key())
+				result := reflect.New(reflect.PtrTo(dest.Type().Elem()))
+				if scope.Err(rows.Scan(key.Interface(), result.Interface())) != nil {
+					return scope
+				}
+
+				if result.Elem().IsNil() {
+					dest.SetMapIndex(key.Elem(), reflect.Zero(dest.Type().Elem()))
+				} else {
+					dest.SetMapIndex(key.Elem(), result.Elem().Elem())
+				}
+			}
+			scope.Err(rows.Err())
+		}
+	case reflect.Slice:
+		var (
+			elemType = dest.Type().Elem()
+			isPtr    = elemType.Kind() == reflect.Ptr
+		)
+		if isPtr {
+			elemType = elemType.Elem()
+		}
+		if elemType.Kind() != reflect.Struct {
+			scope.Err(fmt.Errorf("grouped aggregate results should be a slice of struct, not %v", elemType.Kind()))
+			return scope
+		}
+		dest.Set(reflect.MakeSlice(dest.Type(), 0, 0))
+
+		rows, err := scope.rows()
+		if scope.Err(err) == nil {
+			defer rows.Close()
+			columns, _ := rows.Columns()
+			for rows.Next() {
+				elem := reflect.New(elemType)
+				scope.scan(rows, columns, scope.New(elem.Interface()).Fields())
+				if isPtr {
+					dest.Set(reflect.Append(dest, elem))
+				} else {
+					dest.Set(reflect.Append(dest, elem.Elem()))
+				}
+			}
+			scope.Err(rows.Err())
+		}
+	default:
+		scope.Err(fmt.Errorf("grouped aggregate results should be a map or a slice, not %v", dest.Kind()))
+	}
+	return scope
+}
+
 func (scope *Scope) typeName() string {
 	typ := scope.IndirectValue().Type()
 
@@ -1161,12 +1952,28 @@ func (scope *Scope) createJoinTable(field *StructField) {
 }
 
 func (scope *Scope) createTable() *Scope {
+	scope.createEnumTypes()
+	for _, field := range scope.GetModelStruct().StructFields {
+		scope.createJoinTable(field)
+	}
+
+	scope.Raw(scope.createTableSQL(scope.QuotedTableName(), scope.checks(), nil)).Exec()
+	scope.autoIndex()
+	return scope
+}
+
+// createTableSQL returns the sql creating table for current model with the quoted table name, CHECK constraints and extra columns,
+// columnDefinitions overrides the column definitions of the model
+func (scope *Scope) createTableSQL(quotedTableName string, checks []checkConstraint, columnDefinitions map[string]string, extraColumns ...string) string {
 	var tags []string
 	var primaryKeys []string
 	var primaryKeyInColumnType = false
 	for _, field := range scope.GetModelStruct().StructFields {
 		if field.IsNormal {
-			sqlTag := scope.Dialect().DataTypeOf(field)
+			sqlTag, ok := columnDefinitions[field.DBName]
+			if !ok {
+				sqlTag = scope.Dialect().DataTypeOf(field)
+			}
 
 			// Check if the primary key constraint was specified as
 			// part of the column type. If so, we can only support
@@ -1181,18 +1988,162 @@ func (scope *Scope) createTable() *Scope {
 		if field.IsPrimaryKey {
 			primaryKeys = append(primaryKeys, scope.Quote(field.DBName))
 		}
-		scope.createJoinTable(field)
 	}
 
+	tags = append(tags, extraColumns...)
+
 	var primaryKeyStr string
 	if len(primaryKeys) > 0 && !primaryKeyInColumnType {
 		primaryKeyStr = fmt.Sprintf(", PRIMARY KEY (%v)", strings.Join(primaryKeys, ","))
 	}
 
-	scope.Raw(fmt.Sprintf("CREATE TABLE %v (%v %v)%s", scope.QuotedTableName(), strings.Join(tags, ","), primaryKeyStr, scope.getTableOptions())).Exec()
+	var checkStr string
+	for _, check := range checks {
+		checkStr += ", " + check.sql(scope)
+	}
 
-	scope.autoIndex()
-	return scope
+	return fmt.Sprintf("CREATE TABLE %v (%v %v%v)%s", quotedTableName, strings.Join(tags, ","), primaryKeyStr, checkStr, scope.getTableOptions())
+}
+
+// withoutForeignKeys runs fc in a transaction with sqlite foreign keys disabled, otherwise dropping a table would delete rows of
+// other tables referencing it with `ON DELETE CASCADE`, foreign keys can't be disabled in transactions, so it fails if they are enabled
+func (scope *Scope) withoutForeignKeys(fc func(tx *DB) error) error {
+	db, ok := scope.SQLDB().(*sql.DB)
+	if !ok {
+		var foreignKeys bool
+		if err := scope.NewDB().Raw("PRAGMA foreign_keys").Row().Scan(&foreignKeys); err != nil {
+			return err
+		} else if foreignKeys {
+			return fmt.Errorf("can't rebuild table %v with foreign keys enabled in transaction", scope.TableName())
+		}
+
+		if _, ok := scope.SQLDB().(sqlTx); ok {
+			return fc(scope.NewDB())
+		}
+		return scope.NewDB().Transaction(fc)
+	}
+
+	// the pragma only applies to current connection
+	ctx := scope.Context()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var foreignKeys bool
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return err
+	}
+
+	if foreignKeys {
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return err
+		}
+		defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+	}
+
+	sqlTx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	tx := scope.NewDB()
+	tx.db = sqlTx
+	if err := fc(tx); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+	return sqlTx.Commit()
+}
+
+// rebuildTable recreates the table with current definition and CHECK constraints, and copies existing rows into it, used to alter sqlite tables,
+// columns, indexes and triggers not declared by the model are kept, so are the types and nullability of narrowing columns with setting `gorm:safe_migration`
+func (scope *Scope) rebuildTable(checks []checkConstraint) {
+	var (
+		tableName       = scope.TableName()
+		quotedTableName = scope.QuotedTableName()
+		tempTableName   = tableName + "__temp"
+		columns         []string
+		extraColumns    []string
+	)
+
+	columnTypes, ok := scope.columnTypes()
+	if !ok {
+		return
+	}
+	columnDefinitions := scope.keptColumnDefinitions(columnTypes)
+
+	for _, field := range scope.GetModelStruct().StructFields {
+		if _, ok := columnTypes[field.DBName]; ok && field.IsNormal && !field.IsIgnored {
+			columns = append(columns, scope.Quote(field.DBName))
+			delete(columnTypes, field.DBName)
+		}
+	}
+
+	// keep columns not declared by the model
+	var extraColumnNames []string
+	for name := range columnTypes {
+		extraColumnNames = append(extraColumnNames, name)
+	}
+	sort.Slice(extraColumnNames, func(i, j int) bool {
+		return columnTypes[extraColumnNames[i]].position < columnTypes[extraColumnNames[j]].position
+	})
+
+	for _, name := range extraColumnNames {
+		ct := columnTypes[name]
+		column := scope.Quote(name) + " " + ct.sqlType
+		if ct.notNull {
+			column += " NOT NULL"
+		}
+		if ct.hasDefault {
+			column += " DEFAULT " + ct.defaultValue
+		}
+		columns = append(columns, scope.Quote(name))
+		extraColumns = append(extraColumns, column)
+	}
+
+	// indexes and triggers are dropped with the table, they are recreated after the table rebuilt
+	var schemaSQLs []string
+	rows, err := scope.NewDB().Raw("SELECT sql FROM sqlite_master WHERE type IN ('index', 'trigger') AND tbl_name = ? AND sql IS NOT NULL", tableName).Rows()
+	if scope.Err(err) != nil {
+		return
+	}
+	for rows.Next() {
+		var schemaSQL string
+		if scope.Err(rows.Scan(&schemaSQL)) == nil {
+			schemaSQLs = append(schemaSQLs, schemaSQL)
+		}
+	}
+	rows.Close()
+
+	rebuild := func(tx *DB) error {
+		for _, sql := range append([]string{
+			scope.createTableSQL(scope.Quote(tempTableName), checks, columnDefinitions, extraColumns...),
+			fmt.Sprintf("INSERT INTO %v (%v) SELECT %v FROM %v", scope.Quote(tempTableName), strings.Join(columns, ","), strings.Join(columns, ","), quotedTableName),
+			fmt.Sprintf("DROP TABLE %v", quotedTableName),
+			fmt.Sprintf("ALTER TABLE %v RENAME TO %v", scope.Quote(tempTableName), quotedTableName),
+		}, schemaSQLs...) {
+			if err := tx.Exec(sql).Error; err != nil {
+				return err
+			}
+		}
+
+		// rows of other tables might reference the rebuilt table, which are checked before committed
+		var violations int
+		if err := tx.Raw("SELECT COUNT(*) FROM pragma_foreign_key_check").Row().Scan(&violations); err != nil {
+			return err
+		} else if violations > 0 {
+			return fmt.Errorf("foreign key constraints violated after rebuilding table %v", tableName)
+		}
+		return nil
+	}
+
+	scope.Err(scope.withoutForeignKeys(rebuild))
+
+	if !scope.HasError() {
+		scope.autoIndex()
+	}
 }
 
 func (scope *Scope) dropTable() *Scope {
@@ -1264,6 +2215,7 @@ func (scope *Scope) autoMigrate() *Scope {
 	if !scope.Dialect().HasTable(tableName) {
 		scope.createTable()
 	} else {
+		scope.createEnumTypes()
 		for _, field := range scope.GetModelStruct().StructFields {
 			if !scope.Dialect().HasColumn(tableName, field.DBName) {
 				if field.IsNormal {
@@ -1273,8 +2225,15 @@ func (scope *Scope) autoMigrate() *Scope {
 			}
 			scope.createJoinTable(field)
 		}
+		scope.alterColumns()
+		scope.migrateEnums()
+		scope.migrateChecks()
 		scope.autoIndex()
 	}
+
+	if scope.historical() {
+		scope.autoMigrateHistory()
+	}
 	return scope
 }
 
@@ -2,6 +2,7 @@ package gorm
 
 import (
 	"fmt"
+	"strings"
 )
 
 type search struct {
@@ -17,11 +18,15 @@ type search struct {
 	omits            []string
 	orders           []interface{}
 	preload          []searchPreload
+	ctes             []searchCTE
+	compounds        []searchCompound
+	from             *searchFrom
 	offset           interface{}
 	limit            interface{}
 	group            string
 	tableName        string
 	raw              bool
+	distinct         bool
 	Unscoped         bool
 	ignoreOrderQuery bool
 }
@@ -31,6 +36,22 @@ type searchPreload struct {
 	conditions []interface{}
 }
 
+type searchFrom struct {
+	query interface{}
+	alias string
+}
+
+type searchCompound struct {
+	operator string
+	query    interface{}
+}
+
+type searchCTE struct {
+	name         string
+	query        interface{}
+	materialized []bool
+}
+
 func (s *search) clone() *search {
 	clone := *s
 	return &clone
@@ -77,6 +98,14 @@ func (s *search) Select(query interface{}, args ...interface{}) *search {
 	return s
 }
 
+func (s *search) Distinct(columns ...string) *search {
+	s.distinct = true
+	if len(columns) > 0 {
+		s.Select(strings.Join(columns, ", "))
+	}
+	return s
+}
+
 func (s *search) Omit(columns ...string) *search {
 	s.omits = columns
 	return s
@@ -111,6 +140,28 @@ func (s *search) Joins(query string, values ...interface{}) *search {
 	return s
 }
 
+func (s *search) With(name string, query interface{}, materialized ...bool) *search {
+	var ctes []searchCTE
+	for _, cte := range s.ctes {
+		if cte.name != name {
+			ctes = append(ctes, cte)
+		}
+	}
+	s.ctes = append(ctes, searchCTE{name, query, materialized})
+	return s
+}
+
+func (s *search) Compound(operator string, queries ...interface{}) *search {
+	for _, query := range queries {
+		if len(s.compounds) == 0 {
+			s.compounds = append(s.compounds, searchCompound{query: query})
+		} else {
+			s.compounds = append(s.compounds, searchCompound{operator, query})
+		}
+	}
+	return s
+}
+
 func (s *search) Preload(schema string, values ...interface{}) *search {
 	var preloads []searchPreload
 	for _, preload := range s.preload {
@@ -138,6 +189,12 @@ func (s *search) Table(name string) *search {
 	return s
 }
 
+func (s *search) From(query interface{}, alias string) *search {
+	s.from = &searchFrom{query: query, alias: alias}
+	s.tableName = alias
+	return s
+}
+
 func (s *search) getInterfaceAsSQL(value interface{}) (str string) {
 	switch value.(type) {
 	case string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
@@ -0,0 +1,215 @@
+package gorm
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Serializer serializes values of fields tagged with `serializer:name` into database, and deserializes them back
+//     type User struct {
+//       Attrs     map[string]string `gorm:"serializer:json"`
+//       Addresses []Address         `gorm:"serializer:gob"`
+//       LoginAt   int64             `gorm:"serializer:unixtime"`
+//     }
+type Serializer interface {
+	// Serialize returns the value saved into database
+	Serialize(value interface{}) (interface{}, error)
+	// Deserialize parses the value from database into dest, which is a pointer to the field
+	Deserialize(value interface{}, dest interface{}) error
+	// DataType returns a value of the type saved into database, which decides the column type
+	DataType() interface{}
+}
+
+var serializers sync.Map
+
+func init() {
+	RegisterSerializer("json", jsonSerializer{})
+	RegisterSerializer("gob", gobSerializer{})
+	RegisterSerializer("unixtime", unixTimeSerializer{})
+}
+
+// RegisterSerializer register the serializer with name, which could be used with tag `serializer:name`
+func RegisterSerializer(name string, serializer Serializer) {
+	serializers.Store(strings.ToLower(name), serializer)
+}
+
+// GetSerializer returns the serializer registered with name
+func GetSerializer(name string) (Serializer, bool) {
+	if serializer, ok := serializers.Load(strings.ToLower(name)); ok {
+		return serializer.(Serializer), true
+	}
+	return nil, false
+}
+
+// serializer returns the serializer of the field set with tag `serializer`
+func (sf *StructField) serializer() (Serializer, bool) {
+	if name, ok := sf.TagSettingsGet("SERIALIZER"); ok {
+		return GetSerializer(name)
+	}
+	return nil, false
+}
+
+// serialize returns the value to save into database, which will be serialized if the field has serializer
+func (sf *StructField) serialize(value interface{}) interface{} {
+	if _, ok := value.(*SqlExpr); !ok {
+		if serializer, ok := sf.serializer(); ok {
+			return serializedValue{serializer: serializer, value: value}
+		}
+	}
+	return value
+}
+
+// deserialize parses the value from database into the field, blank values are set to zero values
+func (field *Field) deserialize(serializer Serializer, value interface{}) error {
+	if value == nil {
+		field.Field.Set(reflect.Zero(field.Field.Type()))
+		return nil
+	}
+
+	if !field.Field.CanAddr() {
+		return ErrUnaddressable
+	}
+	return serializer.Deserialize(value, field.Field.Addr().Interface())
+}
+
+type serializedValue struct {
+	serializer Serializer
+	value      interface{}
+}
+
+func (value serializedValue) Value() (driver.Value, error) {
+	return value.serializer.Serialize(value.value)
+}
+
+func serializedBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, fmt.Errorf("failed to deserialize value %v", value)
+}
+
+type jsonSerializer struct{}
+
+func (jsonSerializer) Serialize(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+func (jsonSerializer) Deserialize(value interface{}, dest interface{}) error {
+	data, err := serializedBytes(value)
+	if err == nil && len(data) > 0 {
+		err = json.Unmarshal(data, dest)
+	}
+	return err
+}
+
+func (jsonSerializer) DataType() interface{} {
+	return ""
+}
+
+type gobSerializer struct{}
+
+func (gobSerializer) Serialize(value interface{}) (interface{}, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(value)
+	return buf.Bytes(), err
+}
+
+func (gobSerializer) Deserialize(value interface{}, dest interface{}) error {
+	data, err := serializedBytes(value)
+	if err == nil && len(data) > 0 {
+		err = gob.NewDecoder(bytes.NewReader(data)).Decode(dest)
+	}
+	return err
+}
+
+func (gobSerializer) DataType() interface{} {
+	return []byte{}
+}
+
+// unixTimeSerializer saves unix timestamps of integer fields as time
+type unixTimeSerializer struct{}
+
+func (unixTimeSerializer) Serialize(value interface{}) (interface{}, error) {
+	reflectValue := reflect.Indirect(reflect.ValueOf(value))
+	switch reflectValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return time.Unix(reflectValue.Int(), 0), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return time.Unix(int64(reflectValue.Uint()), 0), nil
+	case reflect.Invalid:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v for unixtime serializer, should be integer", value)
+}
+
+// unixTimeLayouts layouts of time values returned as strings or bytes, e.g. mysql without `parseTime`
+var unixTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999999", "2006-01-02"}
+
+// unixTimeOf returns the unix timestamp of time values, integers, or strings and bytes of them
+func unixTimeOf(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Unix(), nil
+	case *time.Time:
+		if v != nil {
+			return v.Unix(), nil
+		}
+	case []byte, string:
+		str, _ := serializedBytes(v)
+		if unix, err := strconv.ParseInt(string(str), 10, 64); err == nil {
+			return unix, nil
+		}
+		for _, layout := range unixTimeLayouts {
+			if t, err := time.Parse(layout, string(str)); err == nil {
+				return t.Unix(), nil
+			}
+		}
+	default:
+		switch reflectValue := reflect.Indirect(reflect.ValueOf(value)); reflectValue.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return reflectValue.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return int64(reflectValue.Uint()), nil
+		}
+	}
+	return 0, fmt.Errorf("failed to deserialize value %v as time", value)
+}
+
+func (unixTimeSerializer) Deserialize(value interface{}, dest interface{}) error {
+	unix, err := unixTimeOf(value)
+	if err != nil {
+		return err
+	}
+
+	reflectValue := reflect.ValueOf(dest).Elem()
+	if reflectValue.Kind() == reflect.Ptr {
+		reflectValue.Set(reflect.New(reflectValue.Type().Elem()))
+		reflectValue = reflectValue.Elem()
+	}
+
+	switch reflectValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		reflectValue.SetInt(unix)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		reflectValue.SetUint(uint64(unix))
+	default:
+		return fmt.Errorf("unsupported type %v for unixtime serializer, should be integer", reflectValue.Type())
+	}
+	return nil
+}
+
+func (unixTimeSerializer) DataType() interface{} {
+	return time.Time{}
+}
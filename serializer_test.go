@@ -0,0 +1,123 @@
+package gorm_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type SerializerAddress struct {
+	City   string
+	Street string
+}
+
+type SerializerUser struct {
+	ID        uint
+	Name      string
+	Attrs     map[string]string   `gorm:"serializer:json"`
+	Addresses []SerializerAddress `gorm:"serializer:json"`
+	Tags      []string            `gorm:"serializer:gob"`
+	LoginAt   int64               `gorm:"serializer:unixtime"`
+	Roles     []string            `gorm:"serializer:csv"`
+}
+
+// csvSerializer serializes string slices into comma separated values
+type csvSerializer struct{}
+
+func (csvSerializer) Serialize(value interface{}) (interface{}, error) {
+	return strings.Join(value.([]string), ","), nil
+}
+
+func (csvSerializer) Deserialize(value interface{}, dest interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	default:
+		return errors.New("invalid csv value")
+	}
+	*(dest.(*[]string)) = strings.Split(str, ",")
+	return nil
+}
+
+func (csvSerializer) DataType() interface{} {
+	return ""
+}
+
+func TestSerializer(t *testing.T) {
+	gorm.RegisterSerializer("csv", csvSerializer{})
+
+	// settings of the global DB changed by other tests like `gorm:table_options` are reset on the local handle
+	db := DB.New().Set("gorm:table_options", "")
+
+	db.DropTableIfExists(&SerializerUser{})
+	if err := db.AutoMigrate(&SerializerUser{}).Error; err != nil {
+		t.Fatalf("Failed to auto migrate, got %v", err)
+	}
+
+	loginAt := time.Now().Unix()
+	user := SerializerUser{
+		Name:      "serializer",
+		Attrs:     map[string]string{"lang": "en"},
+		Addresses: []SerializerAddress{{City: "Shanghai", Street: "Nanjing Road"}},
+		Tags:      []string{"a", "b"},
+		LoginAt:   loginAt,
+		Roles:     []string{"admin", "dev"},
+	}
+	if err := db.Save(&user).Error; err != nil {
+		t.Fatalf("Should save serialized fields, but got %v", err)
+	}
+
+	var attrs string
+	db.Table("serializer_users").Where("id = ?", user.ID).Select("attrs").Row().Scan(&attrs)
+	if attrs != `{"lang":"en"}` {
+		t.Errorf("Should save fields as json, but got %v", attrs)
+	}
+
+	var result SerializerUser
+	if err := db.First(&result, user.ID).Error; err != nil {
+		t.Fatalf("Should find record, but got %v", err)
+	}
+
+	if !reflect.DeepEqual(result.Attrs, user.Attrs) || !reflect.DeepEqual(result.Addresses, user.Addresses) || !reflect.DeepEqual(result.Tags, user.Tags) || result.LoginAt != loginAt || !reflect.DeepEqual(result.Roles, user.Roles) {
+		t.Errorf("Should deserialize fields, but got %#v", result)
+	}
+
+	db.Model(&result).Updates(map[string]interface{}{"attrs": map[string]string{"lang": "zh"}, "roles": []string{"guest"}})
+	db.First(&result, user.ID)
+	if result.Attrs["lang"] != "zh" || !reflect.DeepEqual(result.Roles, []string{"guest"}) {
+		t.Errorf("Should serialize fields when updating with maps, but got %v, %v", result.Attrs, result.Roles)
+	}
+
+	var field *gorm.Field
+	field, _ = db.NewScope(&result).FieldByName("Attrs")
+	if err := field.Set(`{"lang":"fr"}`); err != nil || result.Attrs["lang"] != "fr" {
+		t.Errorf("Should deserialize values when setting fields, but got %v, %v", result.Attrs, err)
+	}
+
+	field, _ = db.NewScope(&result).FieldByName("LoginAt")
+	for _, value := range []interface{}{time.Unix(loginAt, 0), []byte(strconv.FormatInt(loginAt, 10)), strconv.FormatInt(loginAt, 10), time.Unix(loginAt, 0).UTC().Format("2006-01-02 15:04:05")} {
+		result.LoginAt = 0
+		if err := field.Set(value); err != nil || result.LoginAt != loginAt {
+			t.Errorf("Should deserialize unix time from %#v, but got %v, %v", value, result.LoginAt, err)
+		}
+	}
+
+	var unix int64
+	serializer, _ := gorm.GetSerializer("unixtime")
+	if err := serializer.Deserialize(int32(100), &unix); err != nil || unix != 100 {
+		t.Errorf("Should deserialize unix time from integers, but got %v, %v", unix, err)
+	}
+
+	var count int
+	if db.Model(&SerializerUser{}).Where(&SerializerUser{Roles: []string{"guest"}}).Count(&count); count != 1 {
+		t.Errorf("Should query with serialized fields, but got %v", count)
+	}
+}
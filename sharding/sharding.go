@@ -0,0 +1,284 @@
+// Package sharding splits rows of tables into shard tables by a sharding key
+//     shards := sharding.Register(db, map[string]sharding.Config{
+//       "events": sharding.Modulo("account_id", 64), // events_00 ... events_63
+//     })
+//     shards.AutoMigrate(db, &Event{})
+//
+//     db.Create(&Event{AccountID: 5})                   // INSERT INTO events_05
+//     db.Where("account_id = ?", 5).Find(&events)       // SELECT * FROM events_05 WHERE account_id = 5
+//     db.Where("account_id = ?", 5).Or("account_id = ?", 6).Find(&events) // query the union of events_05 and events_06
+//     db.Set("sharding:all_shards", true).Find(&events) // query all shards
+package sharding
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrMissingShardingKey returned when the sharding key can't be found in the value or conditions
+var ErrMissingShardingKey = errors.New("missing sharding key")
+
+// ErrMultipleShards returned when creating, updating or deleting records of multiple shards
+var ErrMultipleShards = errors.New("sharding key matches multiple shards")
+
+var (
+	// conditionRegexp matches conditions like `column = ?`, `column IN (?)` or `column = @name`, the column name is captured
+	conditionRegexp      = regexp.MustCompile(`(?i)(?:^|[\s(,.])["` + "`" + `\[]?(\w+)["` + "`" + `\]]?\s*(?:=\s*(?:\?|@\w+)|\bIN\b\s*\(\s*(?:\?|@\w+)(?:\s*,\s*(?:\?|@\w+))*\s*\))`)
+	namedParameterRegexp = regexp.MustCompile(`@(\w+)`)
+	// negatedConditionRegexp matches conditions using `OR` or `NOT`, whose values can't be used to find the shards
+	negatedConditionRegexp = regexp.MustCompile(`(?i)\b(?:OR|NOT)\b`)
+)
+
+// Config is the sharding config of a table
+type Config struct {
+	// ShardingKey is the column to shard rows by
+	ShardingKey string
+	// ShardingAlgorithm returns the table suffix of the sharding key value
+	ShardingAlgorithm func(value interface{}) (suffix string, err error)
+	// ShardingSuffixes returns table suffixes of all shards, used by queries across all shards and migrations
+	ShardingSuffixes func() []string
+}
+
+// Modulo returns a config sharding rows by the key modulo number of shards, table suffixes are zero padded like `_00`...`_63`,
+// string keys are hashed with crc32
+func Modulo(key string, number int) Config {
+	format := fmt.Sprintf("_%%0%dd", len(strconv.Itoa(number-1)))
+
+	return Config{
+		ShardingKey: key,
+		ShardingAlgorithm: func(value interface{}) (string, error) {
+			var shard uint64
+			switch reflectValue := reflect.Indirect(reflect.ValueOf(value)); reflectValue.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				if reflectValue.Int() < 0 {
+					return "", fmt.Errorf("invalid sharding key value %v", value)
+				}
+				shard = uint64(reflectValue.Int())
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				shard = reflectValue.Uint()
+			case reflect.String:
+				shard = uint64(crc32.ChecksumIEEE([]byte(reflectValue.String())))
+			default:
+				return "", fmt.Errorf("unsupported sharding key value %v", value)
+			}
+			return fmt.Sprintf(format, shard%uint64(number)), nil
+		},
+		ShardingSuffixes: func() (suffixes []string) {
+			for i := 0; i < number; i++ {
+				suffixes = append(suffixes, fmt.Sprintf(format, i))
+			}
+			return
+		},
+	}
+}
+
+// Sharding keeps sharding configs of tables
+type Sharding struct {
+	configs map[string]Config
+}
+
+// Register register sharding callbacks for tables, table names of creating, querying, updating and deleting are replaced with their shards
+func Register(db *gorm.DB, configs map[string]Config) *Sharding {
+	sharding := &Sharding{configs: configs}
+
+	callback := db.Callback()
+	callback.Create().Before("gorm:begin_transaction").Register("sharding:create", sharding.writeCallback)
+	callback.Update().Before("gorm:assign_updating_attributes").Register("sharding:update", sharding.writeCallback)
+	callback.Delete().Before("gorm:begin_transaction").Register("sharding:delete", sharding.writeCallback)
+	callback.Query().Before("gorm:query_cache").Register("sharding:query", sharding.queryCallback)
+	callback.RowQuery().Before("gorm:row_query").Register("sharding:row_query", sharding.queryCallback)
+	return sharding
+}
+
+// AutoMigrate run auto migration for all shards of sharded tables, and for other tables as usual
+func (sharding *Sharding) AutoMigrate(db *gorm.DB, values ...interface{}) error {
+	for _, value := range values {
+		tableName := db.NewScope(value).TableName()
+		config, ok := sharding.configs[tableName]
+		if !ok {
+			if err := db.AutoMigrate(value).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, suffix := range config.ShardingSuffixes() {
+			if err := db.Table(tableName + suffix).AutoMigrate(value).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tables returns the sharded table name and its shards matched the sharding key, the value is only used when writing
+func (sharding *Sharding) tables(scope *gorm.Scope, withValue bool) (string, []string, bool) {
+	if scope.HasError() || scope.IsRaw() {
+		return "", nil, false
+	}
+
+	tableName := scope.TableName()
+	config, ok := sharding.configs[tableName]
+	if !ok {
+		return "", nil, false
+	}
+
+	var values []interface{}
+	if withValue && scope.IndirectValue().Kind() == reflect.Struct {
+		if field, ok := scope.FieldByName(config.ShardingKey); ok && !field.IsBlank {
+			values = append(values, field.Field.Interface())
+		}
+	}
+
+	for _, condition := range scope.WhereConditions() {
+		args, _ := condition["args"].([]interface{})
+		values = append(values, conditionValues(scope, config.ShardingKey, condition["query"], args)...)
+	}
+
+	// records matching `Or` conditions could be in other shards, so each of them must have the sharding key too
+	for _, condition := range scope.OrConditions() {
+		args, _ := condition["args"].([]interface{})
+		orValues := conditionValues(scope, config.ShardingKey, condition["query"], args)
+		if len(orValues) == 0 || len(values) == 0 {
+			values = nil
+			break
+		}
+		values = append(values, orValues...)
+	}
+
+	var (
+		tables  []string
+		matched = map[string]bool{}
+	)
+	for _, value := range values {
+		suffix, err := config.ShardingAlgorithm(value)
+		if scope.Err(err) != nil {
+			return "", nil, false
+		}
+
+		if !matched[suffix] {
+			matched[suffix] = true
+			tables = append(tables, tableName+suffix)
+		}
+	}
+
+	if len(tables) == 0 && !withValue {
+		if allShards, ok := scope.Get("sharding:all_shards"); ok && allShards == true {
+			for _, suffix := range config.ShardingSuffixes() {
+				tables = append(tables, tableName+suffix)
+			}
+		}
+	}
+
+	if len(tables) == 0 {
+		scope.Err(ErrMissingShardingKey)
+		return "", nil, false
+	}
+	return tableName, tables, true
+}
+
+// writeCallback replace the table name with the shard of the record when creating, updating and deleting
+func (sharding *Sharding) writeCallback(scope *gorm.Scope) {
+	if _, tables, ok := sharding.tables(scope, true); ok {
+		if len(tables) > 1 {
+			scope.Err(ErrMultipleShards)
+			return
+		}
+		scope.Search.Table(tables[0])
+	}
+}
+
+// queryCallback replace the table name with the matched shard, or query from union of matched shards, which uses the table name as alias
+func (sharding *Sharding) queryCallback(scope *gorm.Scope) {
+	if tableName, tables, ok := sharding.tables(scope, false); ok {
+		if len(tables) == 1 {
+			scope.Search.Table(tables[0])
+			return
+		}
+
+		var sqls []string
+		for _, table := range tables {
+			sqls = append(sqls, "SELECT * FROM "+scope.Quote(table))
+		}
+		scope.Search.From(strings.Join(sqls, " UNION ALL "), tableName)
+	}
+}
+
+// conditionValues returns values of the sharding key in the condition, supports maps, structs and conditions like `key = ?`, `key IN (?)` or `key = @name`,
+// conditions using `OR` or `NOT` are ignored as they could match records of other shards
+func conditionValues(scope *gorm.Scope, key string, query interface{}, args []interface{}) (values []interface{}) {
+	switch query := query.(type) {
+	case map[string]interface{}:
+		if value, ok := query[key]; ok {
+			values = append(values, flatten(value)...)
+		}
+	case string:
+		if negatedConditionRegexp.MatchString(query) {
+			return nil
+		}
+
+		for _, loc := range conditionRegexp.FindAllStringSubmatchIndex(query, -1) {
+			if !strings.EqualFold(query[loc[2]:loc[3]], key) {
+				continue
+			}
+
+			for _, name := range namedParameterRegexp.FindAllStringSubmatch(query[loc[0]:loc[1]], -1) {
+				if value, ok := namedValue(scope, name[1], args); ok {
+					values = append(values, flatten(value)...)
+				}
+			}
+
+			for idx := strings.Count(query[:loc[0]], "?"); idx < strings.Count(query[:loc[1]], "?") && idx < len(args); idx++ {
+				values = append(values, flatten(args[idx])...)
+			}
+		}
+	default:
+		if reflect.Indirect(reflect.ValueOf(query)).Kind() == reflect.Struct {
+			if field, ok := scope.New(query).FieldByName(key); ok && !field.IsBlank {
+				values = append(values, field.Field.Interface())
+			}
+		}
+	}
+	return
+}
+
+// namedValue returns the value of named parameter from `sql.NamedArg`s, a `map[string]interface{}` or a struct
+func namedValue(scope *gorm.Scope, name string, args []interface{}) (interface{}, bool) {
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case sql.NamedArg:
+			if arg.Name == name {
+				return arg.Value, true
+			}
+		case map[string]interface{}:
+			if value, ok := arg[name]; ok {
+				return value, true
+			}
+		default:
+			if reflect.Indirect(reflect.ValueOf(arg)).Kind() == reflect.Struct {
+				if field, ok := scope.New(arg).FieldByName(name); ok {
+					return field.Field.Interface(), true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+func flatten(value interface{}) (values []interface{}) {
+	if reflectValue := reflect.ValueOf(value); reflectValue.Kind() == reflect.Slice && reflectValue.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < reflectValue.Len(); i++ {
+			values = append(values, flatten(reflectValue.Index(i).Interface())...)
+		}
+		return
+	}
+	return []interface{}{value}
+}
@@ -0,0 +1,145 @@
+package sharding_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/gorm/internal/dbtest"
+	"github.com/jinzhu/gorm/sharding"
+)
+
+type Account struct {
+	ID     uint
+	Name   string
+	Events []Event
+}
+
+type Event struct {
+	ID        uint
+	AccountID uint
+	Name      string
+}
+
+func openTestDB(t *testing.T) (*gorm.DB, *sharding.Sharding) {
+	db, err := dbtest.Open("gorm_sharding")
+	if err != nil {
+		t.Fatalf("Failed to open database, got %v", err)
+	}
+
+	shards := sharding.Register(db, map[string]sharding.Config{"events": sharding.Modulo("account_id", 4)})
+	db.DropTableIfExists(&Account{}, "events_0", "events_1", "events_2", "events_3")
+	if err := shards.AutoMigrate(db, &Account{}, &Event{}); err != nil {
+		t.Fatalf("Failed to auto migrate, got %v", err)
+	}
+	return db, shards
+}
+
+func TestSharding(t *testing.T) {
+	db, _ := openTestDB(t)
+	defer db.Close()
+
+	for _, table := range []string{"accounts", "events_0", "events_1", "events_2", "events_3"} {
+		if !db.HasTable(table) {
+			t.Errorf("Should create table %v", table)
+		}
+	}
+
+	for i := 1; i <= 6; i++ {
+		account := Account{Name: "account", Events: []Event{{Name: "event"}}}
+		if err := db.Create(&account).Error; err != nil {
+			t.Fatalf("Should create events in shards, but got %v", err)
+		}
+	}
+
+	var count int
+	if db.Table("events_1").Count(&count); count != 2 {
+		t.Errorf("Should create events into the shard, but got %v", count)
+	}
+
+	if err := db.Create(&Event{Name: "event"}).Error; err != sharding.ErrMissingShardingKey {
+		t.Errorf("Should return error when creating without sharding key, but got %v", err)
+	}
+
+	var events []Event
+	if db.Where("account_id = ?", 5).Find(&events); len(events) != 1 || events[0].AccountID != 5 {
+		t.Errorf("Should find events in the shard, but got %v", events)
+	}
+
+	if db.Where(map[string]interface{}{"account_id": 2}).Find(&events); len(events) != 1 || events[0].AccountID != 2 {
+		t.Errorf("Should find events with map conditions, but got %v", events)
+	}
+
+	if db.Where("account_id IN (?)", []uint{1, 2, 5}).Order("account_id").Find(&events); len(events) != 3 || events[2].AccountID != 5 {
+		t.Errorf("Should find events in multiple shards, but got %v", events)
+	}
+
+	if db.Where("account_id = @account", sql.Named("account", 3)).Find(&events); len(events) != 1 || events[0].AccountID != 3 {
+		t.Errorf("Should find events with named parameters, but got %v", events)
+	}
+
+	if db.Where("name = @name AND account_id IN (@accounts)", map[string]interface{}{"name": "event", "accounts": []uint{4, 6}}).Find(&events); len(events) != 2 {
+		t.Errorf("Should find events in multiple shards with named parameters, but got %v", events)
+	}
+
+	if db.Where("account_id = ?", 1).Or("account_id = ?", 2).Order("account_id").Find(&events); len(events) != 2 || events[0].AccountID != 1 || events[1].AccountID != 2 {
+		t.Errorf("Should find events in shards of or conditions, but got %v", events)
+	}
+
+	if err := db.Where("account_id = ?", 1).Or("name = ?", "event").Find(&events).Error; err != sharding.ErrMissingShardingKey {
+		t.Errorf("Should return error when or conditions don't have sharding key, but got %v", err)
+	}
+
+	if err := db.Where("account_id = ? OR name = ?", 1, "event").Find(&events).Error; err != sharding.ErrMissingShardingKey {
+		t.Errorf("Should return error when conditions using OR, but got %v", err)
+	}
+
+	if err := db.Not("account_id = ?", 1).Find(&events).Error; err != sharding.ErrMissingShardingKey {
+		t.Errorf("Should return error when querying with not conditions, but got %v", err)
+	}
+
+	if err := db.Where("NOT account_id = ?", 1).Find(&events).Error; err != sharding.ErrMissingShardingKey {
+		t.Errorf("Should return error when conditions using NOT, but got %v", err)
+	}
+
+	if db.Set("sharding:all_shards", true).Not("account_id = ?", 1).Find(&events); len(events) != 5 {
+		t.Errorf("Should find events across all shards with not conditions, but got %v", events)
+	}
+
+	if err := db.Find(&events).Error; err != sharding.ErrMissingShardingKey {
+		t.Errorf("Should return error when querying without sharding key, but got %v", err)
+	}
+
+	allShards := db.Set("sharding:all_shards", true)
+	if allShards.Order("account_id desc").Limit(2).Find(&events); len(events) != 2 || events[0].AccountID != 6 || events[1].AccountID != 5 {
+		t.Errorf("Should find events across all shards, but got %v", events)
+	}
+
+	if allShards.Model(&Event{}).Count(&count); count != 6 {
+		t.Errorf("Should count events across all shards, but got %v", count)
+	}
+
+	var accounts []Account
+	if db.Preload("Events").Find(&accounts); len(accounts) != 6 || len(accounts[5].Events) != 1 || accounts[5].Events[0].AccountID != accounts[5].ID {
+		t.Errorf("Should preload events from shards, but got %v", accounts)
+	}
+
+	event := accounts[2].Events[0]
+	db.Model(&event).Update("name", "updated")
+	if db.Where("account_id = ? AND name = ?", event.AccountID, "updated").Find(&events); len(events) != 1 {
+		t.Errorf("Should update events in the shard, but got %v", events)
+	}
+
+	if err := db.Model(&Event{}).Where("account_id IN (?)", []uint{1, 2}).Update("name", "updated").Error; err != sharding.ErrMultipleShards {
+		t.Errorf("Should return error when updating multiple shards, but got %v", err)
+	}
+
+	if err := db.Model(&Event{}).Where("account_id = ?", 1).Or("account_id = ?", 2).Update("name", "updated").Error; err != sharding.ErrMultipleShards {
+		t.Errorf("Should return error when updating multiple shards with or conditions, but got %v", err)
+	}
+
+	db.Delete(&event)
+	if allShards.Model(&Event{}).Count(&count); count != 5 {
+		t.Errorf("Should delete events in the shard, but got %v", count)
+	}
+}
@@ -0,0 +1,126 @@
+// Package tenancy scopes models with a tenant field to the current tenant
+//     tenancy.Register(db)
+//
+//     type Order struct {
+//       ID       uint
+//       TenantID uint // or any field tagged with `gorm:"tenant"`
+//     }
+//
+//     db.Set("tenancy:tenant", 1).Find(&orders)
+//     db.WithContext(tenancy.WithTenant(ctx, 1)).Create(&order)
+//     tenancy.Unscoped(db).Find(&orders) // orders of all tenants
+//
+// raw sql can't be scoped, querying tenant scoped models with `Raw` returns ErrRawSQL unless the db is `Unscoped`,
+// `Exec` doesn't run callbacks and is never scoped
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrMissingTenant returned when operating tenant scoped models without a tenant
+var ErrMissingTenant = errors.New("missing tenant for tenant scoped model")
+
+// ErrTenantMismatch returned when creating a record which belongs to another tenant
+var ErrTenantMismatch = errors.New("record belongs to another tenant")
+
+// ErrRawSQL returned when querying tenant scoped models with raw sql, which can't be scoped to the tenant
+var ErrRawSQL = errors.New("raw sql of tenant scoped model can't be scoped to tenant, use tenancy.Unscoped to run it for all tenants")
+
+type tenantKey struct{}
+
+// WithTenant returns a context carrying the tenant, which will be used when `tenancy:tenant` is not set
+func WithTenant(ctx context.Context, tenant interface{}) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// Unscoped returns a db which won't scope models to tenants
+func Unscoped(db *gorm.DB) *gorm.DB {
+	return db.Set("tenancy:unscoped", true)
+}
+
+// Register register tenancy callbacks, conditions of tenant are added to queries, counts, updates and deletes, tenant is assigned to created records
+func Register(db *gorm.DB) {
+	callback := db.Callback()
+	callback.Create().Before("gorm:create").Register("tenancy:assign_tenant", assignTenantCallback)
+	callback.Query().Before("gorm:query_cache").Register("tenancy:scope_tenant", scopeTenantCallback)
+	callback.RowQuery().Before("gorm:row_query").Register("tenancy:scope_tenant", scopeTenantCallback)
+	callback.Update().Before("gorm:update").Register("tenancy:scope_tenant", scopeTenantCallback)
+	callback.Delete().Before("gorm:delete").Register("tenancy:scope_tenant", scopeTenantCallback)
+}
+
+// tenantField returns the field of tenant, which is field `tenant_id` or field tagged with `tenant`
+func tenantField(scope *gorm.Scope) *gorm.StructField {
+	if scope.GetModelStruct().ModelType == nil {
+		return nil
+	}
+
+	for _, field := range scope.GetModelStruct().StructFields {
+		if _, ok := field.TagSettingsGet("TENANT"); (ok || field.DBName == "tenant_id") && field.IsNormal {
+			return field
+		}
+	}
+	return nil
+}
+
+// isUnscoped reports if the db is unscoped with `Unscoped`
+func isUnscoped(scope *gorm.Scope) bool {
+	unscoped, ok := scope.Get("tenancy:unscoped")
+	return ok && unscoped == true
+}
+
+// tenant returns the tenant of the scope, reports if the model should be scoped
+func tenant(scope *gorm.Scope) (*gorm.StructField, interface{}, bool) {
+	if isUnscoped(scope) || scope.HasError() {
+		return nil, nil, false
+	}
+
+	field := tenantField(scope)
+	if field == nil {
+		return nil, nil, false
+	}
+
+	if value, ok := scope.Get("tenancy:tenant"); ok && value != nil {
+		return field, value, true
+	}
+
+	if value := scope.Context().Value(tenantKey{}); value != nil {
+		return field, value, true
+	}
+
+	scope.Err(ErrMissingTenant)
+	return nil, nil, false
+}
+
+func assignTenantCallback(scope *gorm.Scope) {
+	if structField, value, ok := tenant(scope); ok {
+		if field, ok := scope.FieldByName(structField.Name); ok {
+			if field.IsBlank {
+				scope.Err(scope.SetColumn(field, value))
+			} else if fmt.Sprint(field.Field.Interface()) != fmt.Sprint(value) {
+				scope.Err(ErrTenantMismatch)
+			}
+		}
+	}
+}
+
+func scopeTenantCallback(scope *gorm.Scope) {
+	if scope.IsRaw() {
+		if !isUnscoped(scope) && !scope.HasError() {
+			// results of raw sql could also be scanned into tenant scoped models with `Scan`
+			dest, _ := scope.Get("gorm:query_destination")
+			if tenantField(scope) != nil || (dest != nil && tenantField(scope.New(dest)) != nil) {
+				scope.Err(ErrRawSQL)
+			}
+		}
+		return
+	}
+
+	if field, value, ok := tenant(scope); ok {
+		scope.Search.Where(fmt.Sprintf("%v.%v = ?", scope.QuotedTableName(), scope.Quote(field.DBName)), value)
+	}
+}
@@ -0,0 +1,124 @@
+package tenancy_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/gorm/internal/dbtest"
+	"github.com/jinzhu/gorm/tenancy"
+)
+
+type Project struct {
+	ID       uint
+	TenantID uint
+	Name     string
+	Tasks    []Task
+}
+
+type Task struct {
+	ID        uint
+	Owner     uint `gorm:"tenant"`
+	ProjectID uint
+	Name      string
+}
+
+type Setting struct {
+	ID   uint
+	Name string
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	db, err := dbtest.Open("gorm_tenancy")
+	if err != nil {
+		t.Fatalf("Failed to open database, got %v", err)
+	}
+
+	tenancy.Register(db)
+	db.DropTableIfExists(&Project{}, &Task{}, &Setting{})
+	db.AutoMigrate(&Project{}, &Task{}, &Setting{})
+	return db
+}
+
+func TestTenancy(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	tenant1 := db.Set("tenancy:tenant", 1)
+	tenant2 := db.WithContext(tenancy.WithTenant(context.Background(), 2))
+
+	project1 := Project{Name: "project1"}
+	if err := tenant1.Create(&project1).Error; err != nil || project1.TenantID != 1 {
+		t.Fatalf("Should assign tenant when creating, but got %v, %v", err, project1.TenantID)
+	}
+	tenant1.Create(&Task{ProjectID: project1.ID, Name: "task1"})
+
+	project2 := Project{Name: "project2"}
+	tenant2.Create(&project2)
+	tenant2.Create(&Task{ProjectID: project1.ID, Name: "task2"})
+
+	if err := tenant2.Create(&Project{TenantID: 1, Name: "project3"}).Error; err != tenancy.ErrTenantMismatch {
+		t.Errorf("Should not create records of other tenants, but got %v", err)
+	}
+
+	var projects []Project
+	if tenant1.Find(&projects); len(projects) != 1 || projects[0].Name != "project1" {
+		t.Errorf("Should only find records of the tenant, but got %v", projects)
+	}
+
+	var project Project
+	if err := tenant2.First(&project, project1.ID).Error; !gorm.IsRecordNotFoundError(err) {
+		t.Errorf("Should not find records of other tenants, but got %v", err)
+	}
+
+	var count int
+	if tenant1.Model(&Project{}).Count(&count); count != 1 {
+		t.Errorf("Should count records of the tenant, but got %v", count)
+	}
+
+	if tenant1.Preload("Tasks").Find(&projects); len(projects[0].Tasks) != 1 || projects[0].Tasks[0].Name != "task1" {
+		t.Errorf("Should preload records of the tenant, but got %v", projects[0].Tasks)
+	}
+
+	if count := tenant1.Model(&project1).Association("Tasks").Count(); count != 1 {
+		t.Errorf("Should count associations of the tenant, but got %v", count)
+	}
+
+	tenant2.Model(&Project{}).Where("id = ?", project1.ID).Update("name", "hacked")
+	if tenant1.First(&project, project1.ID); project.Name != "project1" {
+		t.Errorf("Should not update records of other tenants, but got %v", project.Name)
+	}
+
+	tenant2.Delete(&project1)
+	if tenant1.Model(&Project{}).Count(&count); count != 1 {
+		t.Errorf("Should not delete records of other tenants, but got %v", count)
+	}
+
+	if err := db.Find(&projects).Error; err != tenancy.ErrMissingTenant {
+		t.Errorf("Should return error when tenant missing, but got %v", err)
+	}
+
+	if err := db.Model(&Project{}).Count(&count).Error; err != tenancy.ErrMissingTenant {
+		t.Errorf("Should return error when counting without tenant, but got %v", err)
+	}
+
+	if tenancy.Unscoped(db).Find(&projects); len(projects) != 2 {
+		t.Errorf("Should find records of all tenants when unscoped, but got %v", projects)
+	}
+
+	if err := tenant1.Raw("SELECT * FROM projects").Find(&projects).Error; err != tenancy.ErrRawSQL {
+		t.Errorf("Should return error when querying tenant scoped models with raw sql, but got %v", err)
+	}
+
+	if err := tenant1.Raw("SELECT * FROM projects").Scan(&projects).Error; err != tenancy.ErrRawSQL {
+		t.Errorf("Should return error when scanning tenant scoped models with raw sql, but got %v", err)
+	}
+
+	if tenancy.Unscoped(db).Raw("SELECT * FROM projects").Find(&projects); len(projects) != 2 {
+		t.Errorf("Should query with raw sql when unscoped, but got %v", projects)
+	}
+
+	if err := db.Create(&Setting{Name: "setting"}).Find(&[]Setting{}).Error; err != nil {
+		t.Errorf("Models without tenant field should not be scoped, but got %v", err)
+	}
+}
@@ -1,5 +1,5 @@
 dialects=("postgres" "mysql" "mssql" "sqlite")
 
 for dialect in "${dialects[@]}" ; do
-    DEBUG=false GORM_DIALECT=${dialect} go test
+    DEBUG=false GORM_DIALECT=${dialect} go test ./...
 done
@@ -224,3 +224,18 @@ func addExtraSpaceIfExist(str string) string {
 	}
 	return ""
 }
+
+// isBinaryColumnType check if the database type of a column stores binary data, values of other types are returned as string when scanned into maps
+func isBinaryColumnType(databaseTypeName string) bool {
+	typeName := strings.ToUpper(databaseTypeName)
+	return strings.Contains(typeName, "BLOB") || strings.Contains(typeName, "BINARY") || typeName == "BYTEA" || typeName == "IMAGE"
+}
+
+// isMapDestination check if the destination is a map or a slice of maps
+func isMapDestination(dest interface{}) bool {
+	destType := reflect.TypeOf(dest)
+	for destType != nil && (destType.Kind() == reflect.Ptr || destType.Kind() == reflect.Slice) {
+		destType = destType.Elem()
+	}
+	return destType != nil && destType.Kind() == reflect.Map
+}